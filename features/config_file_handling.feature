@@ -70,12 +70,21 @@ Feature: 設定ファイルの読み込みと適用
       """
     Then 以下のヘルプメッセージが表示される:
       """
-      Usage: wampa [options]
+      Usage: wampa [command] [options]
+
+      Commands:
+        check              Verify that the output file(s) are up to date
+        update             Refresh wampa.lock with the current remote file contents
 
       Options:
-        -i, --input   Specify input file(s) (can be specified multiple times)
-        -o, --output  Specify output file
-        -h, --help    Display this help message
+        -i, --input        Specify input file(s) (can be specified multiple times)
+        -o, --output       Specify output file
+        -s, --stdin        Read additional content from standard input
+        -f, --format       Specify output format (markdown-comment, xml, html-comment, fenced, json)
+            --once         Write the output file(s) once and exit without watching
+            --update-lock  Accept remote file contents that differ from wampa.lock
+            --offline      Use cached remote files without accessing the network
+        -h, --help         Display this help message
       """
     And プロセスは非ゼロの終了コードで終了する
 
@@ -87,12 +96,21 @@ Feature: 設定ファイルの読み込みと適用
       """
     Then 以下のヘルプメッセージが表示される:
       """
-      Usage: wampa [options]
+      Usage: wampa [command] [options]
+
+      Commands:
+        check              Verify that the output file(s) are up to date
+        update             Refresh wampa.lock with the current remote file contents
 
       Options:
-        -i, --input   Specify input file(s) (can be specified multiple times)
-        -o, --output  Specify output file
-        -h, --help    Display this help message
+        -i, --input        Specify input file(s) (can be specified multiple times)
+        -o, --output       Specify output file
+        -s, --stdin        Read additional content from standard input
+        -f, --format       Specify output format (markdown-comment, xml, html-comment, fenced, json)
+            --once         Write the output file(s) once and exit without watching
+            --update-lock  Accept remote file contents that differ from wampa.lock
+            --offline      Use cached remote files without accessing the network
+        -h, --help         Display this help message
       """
     And プロセスはゼロの終了コードで終了する
 
@@ -113,12 +131,21 @@ Feature: 設定ファイルの読み込みと適用
       """
     Then 以下のヘルプメッセージが表示される:
       """
-      Usage: wampa [options]
+      Usage: wampa [command] [options]
+
+      Commands:
+        check              Verify that the output file(s) are up to date
+        update             Refresh wampa.lock with the current remote file contents
 
       Options:
-        -i, --input   Specify input file(s) (can be specified multiple times)
-        -o, --output  Specify output file
-        -h, --help    Display this help message
+        -i, --input        Specify input file(s) (can be specified multiple times)
+        -o, --output       Specify output file
+        -s, --stdin        Read additional content from standard input
+        -f, --format       Specify output format (markdown-comment, xml, html-comment, fenced, json)
+            --once         Write the output file(s) once and exit without watching
+            --update-lock  Accept remote file contents that differ from wampa.lock
+            --offline      Use cached remote files without accessing the network
+        -h, --help         Display this help message
       """
     And プロセスは非ゼロの終了コードで終了する
 
@@ -134,11 +161,20 @@ Feature: 設定ファイルの読み込みと適用
       """
     Then 以下のヘルプメッセージが表示される:
       """
-      Usage: wampa [options]
+      Usage: wampa [command] [options]
+
+      Commands:
+        check              Verify that the output file(s) are up to date
+        update             Refresh wampa.lock with the current remote file contents
 
       Options:
-        -i, --input   Specify input file(s) (can be specified multiple times)
-        -o, --output  Specify output file
-        -h, --help    Display this help message
+        -i, --input        Specify input file(s) (can be specified multiple times)
+        -o, --output       Specify output file
+        -s, --stdin        Read additional content from standard input
+        -f, --format       Specify output format (markdown-comment, xml, html-comment, fenced, json)
+            --once         Write the output file(s) once and exit without watching
+            --update-lock  Accept remote file contents that differ from wampa.lock
+            --offline      Use cached remote files without accessing the network
+        -h, --help         Display this help message
       """
     And プロセスは非ゼロの終了コードで終了する
\ No newline at end of file
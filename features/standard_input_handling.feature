@@ -1,113 +1,110 @@
-Feature: Standard Input Handling
-  As a user
-  I want to provide content via standard input
-  So that I can dynamically generate content and integrate with shell pipelines
+Feature: 標準入力の読み込み
+  Wampaは-s(--stdin)オプションで標準入力の内容を読み込み、出力ファイルの先頭に追加する
 
   Background:
-    Given Wampa is installed
-    And I have a file named "sample.md" with content:
+    Given 以下の内容のsample.mdが存在する:
       """
-      # Sample File Content
-      This is existing content in a file.
+      # サンプルファイル
+      これはファイルの内容です
       """
 
-  Scenario: Reading from standard input only
-    When I run "wampa -s -o output.md << EOF
-    # Standard Input Content
-    This content comes from standard input.
-    EOF"
-    Then the file "output.md" should contain:
+  @medium
+  Scenario: 標準入力のみの読み込み
+    Given 標準入力に以下の内容を渡す:
       """
-      [//]: # "filepath: stdin"
-      # Standard Input Content
-      This content comes from standard input.
+      # 標準入力の内容
+      これは標準入力から渡された内容です
       """
-    And the exit code should be 0
-
-  Scenario: Reading from standard input and file inputs
-    When I run "wampa -s -i sample.md -o output.md << EOF
-    # Standard Input Content
-    This content comes from standard input.
-    EOF"
-    Then the file "output.md" should contain:
+    When wampaを以下のコマンドで実行:
+      """
+      wampa -s -o output.md --once
+      """
+    Then output.mdの内容は以下の通り:
       """
       [//]: # "filepath: stdin"
-      # Standard Input Content
-      This content comes from standard input.
-
-      [//]: # "filepath: sample.md"
-      # Sample File Content
-      This is existing content in a file.
+      # 標準入力の内容
+      これは標準入力から渡された内容です
       """
-    And the exit code should be 0
+    And プロセスはゼロの終了コードで終了する
 
-  Scenario: Using pipe for standard input
-    When I run "echo '# Piped Content' | wampa -s -i sample.md -o output.md"
-    Then the file "output.md" should contain:
+  @medium
+  Scenario: 標準入力と入力ファイルの読み込み
+    Given 標準入力に以下の内容を渡す:
+      """
+      # 標準入力の内容
+      これは標準入力から渡された内容です
+      """
+    When wampaを以下のコマンドで実行:
+      """
+      wampa -s -i sample.md -o output.md --once
+      """
+    Then output.mdの内容は以下の通り:
       """
       [//]: # "filepath: stdin"
-      # Piped Content
+      # 標準入力の内容
+      これは標準入力から渡された内容です
 
       [//]: # "filepath: sample.md"
-      # Sample File Content
-      This is existing content in a file.
+      # サンプルファイル
+      これはファイルの内容です
       """
-    And the exit code should be 0
+    And プロセスはゼロの終了コードで終了する
 
-  Scenario: Using long form flag for standard input
-    When I run "wampa --stdin -i sample.md -o output.md << EOF
-    # Standard Input Content
-    This content comes from standard input.
-    EOF"
-    Then the file "output.md" should contain:
+  @medium
+  Scenario: 長い形式のオプションでの標準入力の読み込み
+    Given 標準入力に以下の内容を渡す:
+      """
+      # 標準入力の内容
+      """
+    When wampaを以下のコマンドで実行:
+      """
+      wampa --stdin -i sample.md -o output.md --once
+      """
+    Then output.mdの内容は以下の通り:
       """
       [//]: # "filepath: stdin"
-      # Standard Input Content
-      This content comes from standard input.
+      # 標準入力の内容
 
       [//]: # "filepath: sample.md"
-      # Sample File Content
-      This is existing content in a file.
+      # サンプルファイル
+      これはファイルの内容です
       """
-    And the exit code should be 0
+    And プロセスはゼロの終了コードで終了する
 
-  Scenario: Reading from standard input with configuration file
-    Given I have a file named "wampa.json" with content:
+  @medium
+  Scenario: stdinという名前の入力ファイルと標準入力の読み込み
+    Given 以下の内容のstdinが存在する:
+      """
+      # stdinという名前のファイル
+      """
+    And 標準入力に以下の内容を渡す:
+      """
+      # 標準入力の内容
+      """
+    When wampaを以下のコマンドで実行:
       """
-      {
-        "input_files": ["sample.md"],
-        "output_file": "output.md"
-      }
+      wampa -s -i stdin -o output.md --once
       """
-    When I run "wampa -s << EOF
-    # Standard Input Content
-    This content comes from standard input.
-    EOF"
-    Then the file "output.md" should contain:
+    Then output.mdの内容は以下の通り:
       """
       [//]: # "filepath: stdin"
-      # Standard Input Content
-      This content comes from standard input.
+      # 標準入力の内容
 
-      [//]: # "filepath: sample.md"
-      # Sample File Content
-      This is existing content in a file.
+      [//]: # "filepath: stdin"
+      # stdinという名前のファイル
       """
-    And the exit code should be 0
+    And プロセスはゼロの終了コードで終了する
 
-  Scenario: Using pipe with configuration file
-    Given I have a file named "wampa.json" with content:
+  @medium
+  Scenario: 標準入力が空の場合のエラー処理
+    Given 標準入力に何も渡さない
+    When wampaを以下のコマンドで実行:
       """
-      {
-        "input_files": ["sample.md"],
-        "output_file": "output.md"
-      }
+      wampa -s -i sample.md -o output.md --once
       """
-    When I run "curl -s https://raw.githubusercontent.com/toms74209200/wampa/028171afb7eefed15d055b4d82618280c9782f74/TODO.md | wampa -s"
-    Then the file "output.md" should contain content from "stdin" and "sample.md"
-    And the exit code should be 0
-
-  Scenario: Standard input flag with no actual input provided
-    When I run "wampa -s -i sample.md -o output.md"
-    Then the stderr should contain "Error: standard input flag was specified but no content was provided"
-    And the exit code should be 1
\ No newline at end of file
+    Then 以下のエラーメッセージが表示される:
+      """
+      Error: standard input flag was specified but no content was provided
+      """
+    And プロセスは非ゼロの終了コードで終了する
+    And output.mdは作成されない
@@ -0,0 +1,264 @@
+// Package auth provides authentication headers for remote input files
+package auth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TokenSource describes an environment variable that holds a token for a set of hosts
+type TokenSource struct {
+	// Hosts are the host names the token is sent to
+	Hosts []string
+	// Envs are the environment variables checked in order
+	Envs []string
+	// Header is the name of the header that carries the token
+	Header string
+	// Prefix is prepended to the token in the header value
+	Prefix string
+}
+
+// DefaultTokenSources are the tokens picked up from the environment automatically
+var DefaultTokenSources = []TokenSource{
+	{
+		Hosts:  []string{"github.com", "api.github.com", "raw.githubusercontent.com", "gist.githubusercontent.com"},
+		Envs:   []string{"GITHUB_TOKEN", "GH_TOKEN"},
+		Header: "Authorization",
+		Prefix: "Bearer ",
+	},
+	{
+		Hosts:  []string{"gitlab.com"},
+		Envs:   []string{"GITLAB_TOKEN"},
+		Header: "PRIVATE-TOKEN",
+	},
+}
+
+// Resolver decides the headers sent with the request for each remote URL.
+// Headers configured for a URL take precedence over headers configured for its host,
+// followed by tokens from the environment and credentials from .netrc.
+type Resolver struct {
+	urlHeaders  map[string]http.Header
+	hostHeaders map[string]http.Header
+	tokens      map[string]http.Header
+	netrc       []Machine
+	secrets     []string
+}
+
+// NewResolver creates a Resolver.
+// Header values may reference environment variables as ${NAME}; they are expanded with getenv,
+// and referencing an unset variable is an error.
+func NewResolver(urlHeaders, hostHeaders map[string]map[string]string, sources []TokenSource, netrc []Machine, getenv func(string) string) (*Resolver, error) {
+	r := &Resolver{
+		urlHeaders:  make(map[string]http.Header),
+		hostHeaders: make(map[string]http.Header),
+		tokens:      make(map[string]http.Header),
+		netrc:       netrc,
+	}
+
+	expand := func(configured map[string]map[string]string, target map[string]http.Header, kind string) error {
+		for key, headers := range configured {
+			h := make(http.Header)
+			for name, value := range headers {
+				expanded, vars, err := ExpandEnv(value, getenv)
+				if err != nil {
+					return fmt.Errorf("header %s for %s %s: %w", name, kind, key, err)
+				}
+				h.Set(name, expanded)
+				r.addSecrets(vars...)
+				if isSecretHeader(name) {
+					r.addSecrets(expanded)
+				}
+			}
+			target[key] = h
+		}
+		return nil
+	}
+	if err := expand(urlHeaders, r.urlHeaders, "URL"); err != nil {
+		return nil, err
+	}
+	if err := expand(hostHeaders, r.hostHeaders, "host"); err != nil {
+		return nil, err
+	}
+
+	for _, source := range sources {
+		token := ""
+		for _, env := range source.Envs {
+			if token = getenv(env); token != "" {
+				break
+			}
+		}
+		if token == "" {
+			continue
+		}
+		r.addSecrets(token)
+		for _, host := range source.Hosts {
+			if _, ok := r.tokens[host]; ok {
+				continue
+			}
+			h := make(http.Header)
+			h.Set(source.Header, source.Prefix+token)
+			r.tokens[host] = h
+		}
+	}
+
+	for _, m := range netrc {
+		r.addSecrets(m.Password, basicAuth(m.Login, m.Password))
+	}
+
+	return r, nil
+}
+
+// addSecrets records values that must never appear in logs
+func (r *Resolver) addSecrets(values ...string) {
+	for _, value := range values {
+		if value != "" {
+			r.secrets = append(r.secrets, value)
+		}
+	}
+}
+
+// Secrets returns the credential values known to the resolver
+func (r *Resolver) Secrets() []string {
+	return append([]string(nil), r.secrets...)
+}
+
+// Headers returns the headers to send with a request for rawURL
+func (r *Resolver) Headers(rawURL string) http.Header {
+	h := make(http.Header)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return h
+	}
+
+	merge := func(from http.Header) {
+		for name, values := range from {
+			if _, ok := h[name]; !ok {
+				h[name] = append([]string(nil), values...)
+			}
+		}
+	}
+	merge(r.urlHeaders[rawURL])
+	merge(r.hostHeaders[u.Host])
+	merge(r.hostHeaders[u.Hostname()])
+
+	// Credentials found automatically are only sent over HTTPS and never replace configured authorization
+	if u.Scheme != "https" || h.Get("Authorization") != "" {
+		return h
+	}
+	if token, ok := r.tokens[u.Hostname()]; ok {
+		merge(token)
+		return h
+	}
+	if m, ok := findMachine(r.netrc, u.Hostname()); ok {
+		h.Set("Authorization", basicAuth(m.Login, m.Password))
+	}
+	return h
+}
+
+// maxRedirects is the number of redirects followed, as by http.Client without CheckRedirect
+const maxRedirects = 10
+
+// CheckRedirect prepares a redirected request for use as http.Client.CheckRedirect.
+// http.Client copies every header of the original request to the redirect except a few well-known credentials,
+// so when the redirect leaves the host of the original request, the headers resolved for the original URL are removed.
+// The headers resolved for the new URL are then added.
+func (r *Resolver) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	original := via[0].URL
+	if req.URL.Host != original.Host {
+		for name, values := range r.Headers(original.String()) {
+			// Headers set by the caller with other values are kept
+			if req.Header.Get(name) == values[0] {
+				req.Header.Del(name)
+			}
+		}
+	}
+	for name, values := range r.Headers(req.URL.String()) {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = values
+		}
+	}
+	return nil
+}
+
+// Client wraps an HTTP client so that every request carries the headers resolved for its URL
+func (r *Resolver) Client(base Doer) Doer {
+	return &client{base: base, resolver: r}
+}
+
+// Doer defines the port for executing HTTP requests
+type Doer interface {
+	// Do sends an HTTP request and returns an HTTP response
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// client adds resolved headers to requests
+type client struct {
+	base     Doer
+	resolver *Resolver
+}
+
+// Do sends the request with its resolved headers; headers already set on the request are kept
+func (c *client) Do(req *http.Request) (*http.Response, error) {
+	headers := c.resolver.Headers(req.URL.String())
+	if len(headers) == 0 {
+		return c.base.Do(req)
+	}
+
+	req = req.Clone(req.Context())
+	for name, values := range headers {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = values
+		}
+	}
+	return c.base.Do(req)
+}
+
+// ExpandEnv replaces ${NAME} references in s with the values returned by getenv.
+// It also returns the substituted values. Referencing an unset or empty variable is an error.
+// This is a pure function that can be easily tested
+func ExpandEnv(s string, getenv func(string) string) (string, []string, error) {
+	var b strings.Builder
+	var values []string
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), values, nil
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			return "", nil, fmt.Errorf("unterminated variable reference in %q", s)
+		}
+		name := s[start+2 : start+end]
+		if name == "" {
+			return "", nil, fmt.Errorf("empty variable reference in %q", s)
+		}
+		value := getenv(name)
+		if value == "" {
+			return "", nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		values = append(values, value)
+		s = s[start+end+1:]
+	}
+}
+
+// isSecretHeader reports whether a header usually carries credentials
+func isSecretHeader(name string) bool {
+	name = strings.ToLower(name)
+	return name == "authorization" || name == "proxy-authorization" || name == "cookie" ||
+		strings.Contains(name, "token") || strings.Contains(name, "key") || strings.Contains(name, "secret")
+}
+
+// basicAuth returns the value of a basic Authorization header
+func basicAuth(login, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(login+":"+password))
+}
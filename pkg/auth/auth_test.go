@@ -0,0 +1,238 @@
+//go:build small
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testEnv(env map[string]string) func(string) string {
+	return func(name string) string { return env[name] }
+}
+
+func TestExpandEnv(t *testing.T) {
+	getenv := testEnv(map[string]string{"TOKEN": "s3cr3t", "USER": "alice"})
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "no references", input: "plain", want: "plain"},
+		{name: "single reference", input: "Bearer ${TOKEN}", want: "Bearer s3cr3t"},
+		{name: "multiple references", input: "${USER}:${TOKEN}", want: "alice:s3cr3t"},
+		{name: "bare dollar is kept", input: "$TOKEN", want: "$TOKEN"},
+		{name: "unset variable", input: "${MISSING}", wantErr: true},
+		{name: "unterminated reference", input: "${TOKEN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := ExpandEnv(tt.input, getenv)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Headers(t *testing.T) {
+	env := testEnv(map[string]string{"GITHUB_TOKEN": "gh-token", "GITEA_TOKEN": "gitea-token"})
+	urlHeaders := map[string]map[string]string{
+		"https://raw.githubusercontent.com/org/private/main/rules.md": {"Authorization": "token ${GITEA_TOKEN}"},
+	}
+	hostHeaders := map[string]map[string]string{
+		"gitea.internal:3000": {"Authorization": "token ${GITEA_TOKEN}", "X-Team": "docs"},
+	}
+	netrc := []Machine{{Name: "files.example.com", Login: "alice", Password: "pw"}}
+
+	r, err := NewResolver(urlHeaders, hostHeaders, DefaultTokenSources, netrc, env)
+	if err != nil {
+		t.Fatalf("NewResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want http.Header
+	}{
+		{
+			name: "per-URL header overrides token",
+			url:  "https://raw.githubusercontent.com/org/private/main/rules.md",
+			want: http.Header{"Authorization": {"token gitea-token"}},
+		},
+		{
+			name: "bearer token from environment",
+			url:  "https://raw.githubusercontent.com/org/other/main/rules.md",
+			want: http.Header{"Authorization": {"Bearer gh-token"}},
+		},
+		{
+			name: "token is not sent over HTTP",
+			url:  "http://raw.githubusercontent.com/org/other/main/rules.md",
+			want: http.Header{},
+		},
+		{
+			name: "per-host headers",
+			url:  "https://gitea.internal:3000/org/rules/raw/main/rules.md",
+			want: http.Header{"Authorization": {"token gitea-token"}, "X-Team": {"docs"}},
+		},
+		{
+			name: "netrc credentials",
+			url:  "https://files.example.com/rules.md",
+			want: http.Header{"Authorization": {"Basic YWxpY2U6cHc="}},
+		},
+		{
+			name: "unknown host",
+			url:  "https://example.org/rules.md",
+			want: http.Header{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.Headers(tt.url)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Headers() = %v, want %v", got, tt.want)
+			}
+			for name := range tt.want {
+				if got.Get(name) != tt.want.Get(name) {
+					t.Errorf("Headers()[%s] = %q, want %q", name, got.Get(name), tt.want.Get(name))
+				}
+			}
+		})
+	}
+
+	secrets := r.Secrets()
+	for _, want := range []string{"gh-token", "gitea-token", "pw"} {
+		found := false
+		for _, secret := range secrets {
+			if secret == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Secrets() does not contain %q", want)
+		}
+	}
+}
+
+func TestNewResolver_UnsetVariable(t *testing.T) {
+	hostHeaders := map[string]map[string]string{
+		"gitlab.example.com": {"PRIVATE-TOKEN": "${GITLAB_TOKEN}"},
+	}
+	if _, err := NewResolver(nil, hostHeaders, nil, nil, testEnv(nil)); err == nil {
+		t.Errorf("NewResolver() error = nil, want error for unset variable")
+	}
+}
+
+// mockDoer records the last request
+type mockDoer struct {
+	req *http.Request
+}
+
+func (m *mockDoer) Do(req *http.Request) (*http.Response, error) {
+	m.req = req
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestResolver_Client(t *testing.T) {
+	r, err := NewResolver(nil, nil, DefaultTokenSources, nil, testEnv(map[string]string{"GITHUB_TOKEN": "gh-token"}))
+	if err != nil {
+		t.Fatalf("NewResolver() error = %v", err)
+	}
+	base := &mockDoer{}
+	req, _ := http.NewRequest(http.MethodGet, "https://raw.githubusercontent.com/org/repo/main/a.md", nil)
+	req.Header.Set("If-None-Match", `"v1"`)
+
+	if _, err := r.Client(base).Do(req); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got := base.req.Header.Get("Authorization"); got != "Bearer gh-token" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer gh-token")
+	}
+	if got := base.req.Header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("If-None-Match = %q, want %q", got, `"v1"`)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Errorf("Do() modified the original request")
+	}
+}
+
+func TestResolver_CheckRedirect(t *testing.T) {
+	var received http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req.Header.Clone()
+	}))
+	defer other.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/same":
+			http.Redirect(w, req, "/target", http.StatusFound)
+		case "/other":
+			http.Redirect(w, req, other.URL+"/target", http.StatusFound)
+		default:
+			received = req.Header.Clone()
+		}
+	}))
+	defer origin.Close()
+
+	originHost := strings.TrimPrefix(origin.URL, "http://")
+	otherHost := strings.TrimPrefix(other.URL, "http://")
+	r, err := NewResolver(
+		map[string]map[string]string{origin.URL + "/other": {"X-Url-Token": "url-token"}},
+		map[string]map[string]string{
+			originHost: {"PRIVATE-TOKEN": "origin-token", "X-API-Key": "origin-key"},
+			otherHost:  {"X-API-Key": "other-key"},
+		},
+		nil, nil, testEnv(nil))
+	if err != nil {
+		t.Fatalf("NewResolver() error = %v", err)
+	}
+	client := r.Client(&http.Client{CheckRedirect: r.CheckRedirect})
+
+	tests := []struct {
+		name string
+		path string
+		want map[string]string
+	}{
+		{
+			name: "same host keeps the headers",
+			path: "/same",
+			want: map[string]string{"PRIVATE-TOKEN": "origin-token", "X-API-Key": "origin-key", "If-None-Match": `"v1"`},
+		},
+		{
+			name: "other host gets only its own headers",
+			path: "/other",
+			want: map[string]string{"PRIVATE-TOKEN": "", "X-Url-Token": "", "X-API-Key": "other-key", "If-None-Match": `"v1"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			req, _ := http.NewRequest(http.MethodGet, origin.URL+tt.path, nil)
+			req.Header.Set("If-None-Match", `"v1"`)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if received == nil {
+				t.Fatal("the redirect was not followed")
+			}
+			for name, want := range tt.want {
+				if got := received.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Machine is a single entry of a .netrc file.
+// The default entry has an empty Name.
+type Machine struct {
+	Name     string
+	Login    string
+	Password string
+}
+
+// ParseNetrc parses the content of a .netrc file.
+// Macro definitions are skipped, and entries without a password are dropped.
+// This is a pure function that can be easily tested
+func ParseNetrc(data string) []Machine {
+	var machines []Machine
+	var current *Machine
+	flush := func() {
+		if current != nil && current.Password != "" {
+			machines = append(machines, *current)
+		}
+		current = nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	inMacro := false
+	for _, line := range lines {
+		if inMacro {
+			// A macro definition ends at the first blank line
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			if strings.HasPrefix(fields[i], "#") {
+				break
+			}
+			next := func() string {
+				if i+1 < len(fields) {
+					i++
+					return fields[i]
+				}
+				return ""
+			}
+			switch fields[i] {
+			case "machine":
+				flush()
+				current = &Machine{Name: next()}
+			case "default":
+				flush()
+				current = &Machine{}
+			case "login":
+				if current != nil {
+					current.Login = next()
+				}
+			case "password":
+				if current != nil {
+					current.Password = next()
+				}
+			case "account":
+				next()
+			case "macdef":
+				next()
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	flush()
+	return machines
+}
+
+// LoadNetrc reads the .netrc file named by the NETRC environment variable,
+// or the one in the home directory. A missing file yields no entries.
+func LoadNetrc(getenv func(string) string) ([]Machine, error) {
+	path := getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		path = filepath.Join(home, name)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ParseNetrc(string(data)), nil
+}
+
+// findMachine returns the entry for host, falling back to the default entry
+func findMachine(machines []Machine, host string) (Machine, bool) {
+	var fallback *Machine
+	for i, m := range machines {
+		if m.Name == host {
+			return m, true
+		}
+		if m.Name == "" && fallback == nil {
+			fallback = &machines[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return Machine{}, false
+}
@@ -0,0 +1,70 @@
+//go:build small
+
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Machine
+	}{
+		{
+			name:  "single line entries",
+			input: "machine a.example.com login alice password pw1\nmachine b.example.com login bob password pw2\n",
+			want: []Machine{
+				{Name: "a.example.com", Login: "alice", Password: "pw1"},
+				{Name: "b.example.com", Login: "bob", Password: "pw2"},
+			},
+		},
+		{
+			name:  "multi line entry with default",
+			input: "machine a.example.com\n  login alice\n  password pw1\n\ndefault login anonymous password guest\n",
+			want: []Machine{
+				{Name: "a.example.com", Login: "alice", Password: "pw1"},
+				{Login: "anonymous", Password: "guest"},
+			},
+		},
+		{
+			name:  "macro definitions and comments are skipped",
+			input: "# credentials\nmacdef init\ncd /pub\n\nmachine a.example.com login alice password pw1 # work\n",
+			want: []Machine{
+				{Name: "a.example.com", Login: "alice", Password: "pw1"},
+			},
+		},
+		{
+			name:  "entry without password",
+			input: "machine a.example.com login alice\n",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseNetrc(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNetrc() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindMachine(t *testing.T) {
+	machines := []Machine{
+		{Login: "anonymous", Password: "guest"},
+		{Name: "a.example.com", Login: "alice", Password: "pw1"},
+	}
+
+	if m, ok := findMachine(machines, "a.example.com"); !ok || m.Login != "alice" {
+		t.Errorf("findMachine() = %+v, %v, want alice", m, ok)
+	}
+	if m, ok := findMachine(machines, "other.example.com"); !ok || m.Login != "anonymous" {
+		t.Errorf("findMachine() = %+v, %v, want default entry", m, ok)
+	}
+	if _, ok := findMachine(machines[1:], "other.example.com"); ok {
+		t.Errorf("findMachine() found an entry without a default")
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in redacted output
+const Redacted = "[REDACTED]"
+
+// userinfoPattern matches the password of credentials embedded in URLs
+var userinfoPattern = regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`)
+
+// Redact replaces every secret in s, and every password embedded in a URL, with Redacted.
+// This is a pure function that can be easily tested
+func Redact(s string, secrets []string) string {
+	// Replace longer secrets first so that a secret containing another is fully hidden
+	sorted := append([]string(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	return userinfoPattern.ReplaceAllString(s, "${1}"+Redacted+"@")
+}
+
+// Redactor is an io.Writer that redacts secrets before writing to another writer.
+// Each Write is redacted on its own, which suits the log package writing one line per call.
+type Redactor struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets []string
+}
+
+// NewRedactor creates a Redactor that writes to w
+func NewRedactor(w io.Writer, secrets []string) *Redactor {
+	return &Redactor{w: w, secrets: secrets}
+}
+
+// Write writes p to the underlying writer with secrets redacted.
+// It reports len(p) on success so that callers see a complete write.
+func (r *Redactor) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := io.WriteString(r.w, Redact(string(p), r.secrets)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,176 @@
+// Package cache provides the on-disk cache of remote input files
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	outputfile "github.com/toms74209200/wampa/pkg/output"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+// dirName is the name of the wampa directory inside the user cache directory
+const dirName = "wampa"
+
+const (
+	// dirPerm is the permission of the cache directory
+	dirPerm = 0o700
+	// filePerm is the permission of cached files
+	filePerm = 0o600
+)
+
+// Entry is a cached remote file
+type Entry struct {
+	// State is the metadata of the response the content came from
+	State watcher.RemoteFileState
+	// Content is the body of the remote file
+	Content []byte
+	// FetchedAt is the last time the content was confirmed by the server
+	FetchedAt time.Time
+}
+
+// Age returns how long ago the entry was confirmed by the server
+func (e Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.FetchedAt)
+}
+
+// metadata is the JSON representation of the non-content part of an Entry,
+// with the hash of the content file it belongs to
+type metadata struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Size         int64     `json:"size"`
+	SHA256       string    `json:"sha256"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// Cache stores remote files in a directory keyed by URL.
+// A nil Cache stores nothing.
+type Cache struct {
+	dir string
+}
+
+// DefaultDir returns the cache directory under XDG_CACHE_HOME,
+// or the platform's user cache directory when it is not set
+func DefaultDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating cache directory: %w", err)
+	}
+	return filepath.Join(base, dirName), nil
+}
+
+// New creates a Cache that stores entries in dir
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// key returns the file name prefix of the entry for a URL
+func key(url string) string {
+	return hash([]byte(url))
+}
+
+// hash returns the hex-encoded SHA-256 hash of data
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// paths returns the paths of the metadata and content files of a URL
+func (c *Cache) paths(url string) (meta, body string) {
+	base := filepath.Join(c.dir, key(url))
+	return base + ".json", base + ".body"
+}
+
+// Get returns the cached entry of a URL.
+// It reports false when the URL is not cached or its entry is incomplete.
+func (c *Cache) Get(url string) (Entry, bool, error) {
+	if c == nil {
+		return Entry{}, false, nil
+	}
+
+	metaPath, bodyPath := c.paths(url)
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Entry{}, false, nil
+		}
+		return Entry{}, false, fmt.Errorf("reading cache entry for %s: %w", url, err)
+	}
+	var meta metadata
+	if err := json.Unmarshal(data, &meta); err != nil || meta.URL != url {
+		// A corrupt entry or a hash collision is treated as a miss
+		return Entry{}, false, nil
+	}
+
+	content, err := os.ReadFile(bodyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Entry{}, false, nil
+		}
+		return Entry{}, false, fmt.Errorf("reading cache entry for %s: %w", url, err)
+	}
+	// A content file that does not belong to the metadata is left from an interrupted Put
+	if int64(len(content)) != meta.Size || hash(content) != meta.SHA256 {
+		return Entry{}, false, nil
+	}
+
+	return Entry{
+		State: watcher.RemoteFileState{
+			URL:          meta.URL,
+			ETag:         meta.ETag,
+			LastModified: meta.LastModified,
+			ContentType:  meta.ContentType,
+			Size:         meta.Size,
+		},
+		Content:   content,
+		FetchedAt: meta.FetchedAt,
+	}, true, nil
+}
+
+// Put stores an entry, replacing any previous entry of its URL.
+// The content is written before the metadata, which records the hash of the content,
+// so an entry whose files are not both written is never returned by Get.
+func (c *Cache) Put(entry Entry) error {
+	if c == nil {
+		return nil
+	}
+
+	// Entries may hold private content fetched with credentials, so only the user can read them
+	if err := os.MkdirAll(c.dir, dirPerm); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	if err := os.Chmod(c.dir, dirPerm); err != nil {
+		return fmt.Errorf("setting permissions of cache directory: %w", err)
+	}
+
+	meta := metadata{
+		URL:          entry.State.URL,
+		ETag:         entry.State.ETag,
+		LastModified: entry.State.LastModified,
+		ContentType:  entry.State.ContentType,
+		Size:         int64(len(entry.Content)),
+		SHA256:       hash(entry.Content),
+		FetchedAt:    entry.FetchedAt.UTC(),
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cache entry for %s: %w", meta.URL, err)
+	}
+
+	metaPath, bodyPath := c.paths(meta.URL)
+	if _, err := outputfile.WriteMode(bodyPath, entry.Content, filePerm); err != nil {
+		return fmt.Errorf("writing cache entry for %s: %w", meta.URL, err)
+	}
+	if _, err := outputfile.WriteMode(metaPath, data, filePerm); err != nil {
+		return fmt.Errorf("writing cache entry for %s: %w", meta.URL, err)
+	}
+	return nil
+}
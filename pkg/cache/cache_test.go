@@ -0,0 +1,143 @@
+//go:build small
+
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+const testURL = "https://example.com/rules.md"
+
+func TestCache_PutAndGet(t *testing.T) {
+	c := New(t.TempDir())
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, ok, err := c.Get(testURL); ok || err != nil {
+		t.Fatalf("Get() on empty cache = %v, %v", ok, err)
+	}
+
+	entry := Entry{
+		State: watcher.RemoteFileState{
+			URL:          testURL,
+			ETag:         `"v1"`,
+			LastModified: "Wed, 01 May 2024 12:00:00 GMT",
+			ContentType:  "text/markdown",
+		},
+		Content:   []byte("# ルール"),
+		FetchedAt: fetchedAt,
+	}
+	if err := c.Put(entry); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	got, ok, err := c.Get(testURL)
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if !bytes.Equal(got.Content, entry.Content) {
+		t.Errorf("Get() Content = %q, want %q", got.Content, entry.Content)
+	}
+	if got.State.ETag != entry.State.ETag || got.State.LastModified != entry.State.LastModified {
+		t.Errorf("Get() State = %+v, want %+v", got.State, entry.State)
+	}
+	if got.State.Size != int64(len(entry.Content)) {
+		t.Errorf("Get() Size = %d, want %d", got.State.Size, len(entry.Content))
+	}
+	if !got.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("Get() FetchedAt = %v, want %v", got.FetchedAt, fetchedAt)
+	}
+
+	if _, ok, _ := c.Get("https://example.com/other.md"); ok {
+		t.Errorf("Get() returned an entry for another URL")
+	}
+}
+
+func TestCache_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions are not supported on Windows")
+	}
+
+	dir := filepath.Join(t.TempDir(), "wampa")
+	// A directory and an entry left by an earlier version are made private too
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	c := New(dir)
+	metaPath, bodyPath := c.paths(testURL)
+	if err := os.WriteFile(bodyPath, []byte("# ルール"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Put(Entry{State: watcher.RemoteFileState{URL: testURL}, Content: []byte("# ルール")}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	for path, want := range map[string]os.FileMode{dir: 0o700, metaPath: 0o600, bodyPath: 0o600} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("mode of %s = %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestCache_IncompleteEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "truncated content", body: "trunc"},
+		// Put was interrupted after writing the content of a new version with the same size
+		{name: "content of another version", body: "CONTENT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(t.TempDir())
+			if err := c.Put(Entry{State: watcher.RemoteFileState{URL: testURL, ETag: `"v1"`}, Content: []byte("content")}); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+
+			_, bodyPath := c.paths(testURL)
+			if err := os.WriteFile(bodyPath, []byte(tt.body), 0o644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if _, ok, err := c.Get(testURL); ok || err != nil {
+				t.Errorf("Get() on incomplete entry = %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestCache_Nil(t *testing.T) {
+	var c *Cache
+	if err := c.Put(Entry{State: watcher.RemoteFileState{URL: testURL}}); err != nil {
+		t.Errorf("Put() on nil cache error = %v", err)
+	}
+	if _, ok, err := c.Get(testURL); ok || err != nil {
+		t.Errorf("Get() on nil cache = %v, %v", ok, err)
+	}
+}
+
+func TestDefaultDir(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("XDG_CACHE_HOME is not used on " + runtime.GOOS)
+	}
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+	dir, err := DefaultDir()
+	if err != nil {
+		t.Fatalf("DefaultDir() error = %v", err)
+	}
+	if dir != "/tmp/xdg-cache/wampa" {
+		t.Errorf("DefaultDir() = %q, want %q", dir, "/tmp/xdg-cache/wampa")
+	}
+}
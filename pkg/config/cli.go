@@ -12,17 +12,41 @@ const (
 	OutputFileFlagLong = "--output"
 	ConfigFileFlag     = "-c"
 	ConfigFileFlagLong = "--config"
+	StdinFlag          = "-s"
+	StdinFlagLong      = "--stdin"
+	FormatFlag         = "-f"
+	FormatFlagLong     = "--format"
+	OnceFlagLong       = "--once"
+	UpdateLockFlagLong = "--update-lock"
+	OfflineFlagLong    = "--offline"
 	HelpFlag           = "-h"
 	HelpFlagLong       = "--help"
 )
 
+// Subcommand definitions
+const (
+	// CommandCheck verifies that the output files are up to date without writing them
+	CommandCheck = "check"
+	// CommandUpdate refreshes the lockfile entries of remote input files
+	CommandUpdate = "update"
+)
+
 // Help message definition
-const HelpMessage = `Usage: wampa [options]
+const HelpMessage = `Usage: wampa [command] [options]
+
+Commands:
+  check              Verify that the output file(s) are up to date
+  update             Refresh wampa.lock with the current remote file contents
 
 Options:
-  -i, --input   Specify input file(s) (can be specified multiple times)
-  -o, --output  Specify output file
-  -h, --help    Display this help message`
+  -i, --input        Specify input file(s) (can be specified multiple times)
+  -o, --output       Specify output file
+  -s, --stdin        Read additional content from standard input
+  -f, --format       Specify output format (markdown-comment, xml, html-comment, fenced, json)
+      --once         Write the output file(s) once and exit without watching
+      --update-lock  Accept remote file contents that differ from wampa.lock
+      --offline      Use cached remote files without accessing the network
+  -h, --help         Display this help message`
 
 // CheckHelpFlag checks if help flag is present in arguments
 func CheckHelpFlag(args []string) bool {
@@ -36,9 +60,19 @@ func CheckHelpFlag(args []string) bool {
 
 // CLIOptions represents command-line arguments
 type CLIOptions struct {
-	InputFiles []string
-	OutputFile string
-	ConfigFile string
+	InputFiles   []string
+	OutputFile   string
+	ConfigFile   string
+	Stdin        bool
+	OutputFormat string
+	// Once writes the outputs a single time instead of watching for changes
+	Once bool
+	// UpdateLock accepts remote contents that differ from the lockfile
+	UpdateLock bool
+	// Offline serves remote files only from the cache
+	Offline bool
+	// Command is the subcommand given before the options; empty means watching
+	Command string
 }
 
 // NewCLIOptions creates a new CLIOptions with default values
@@ -52,6 +86,16 @@ func NewCLIOptions() *CLIOptions {
 
 // ParseFlags parses command line arguments and returns CLIOptions
 func ParseFlags(_ interface{}, args []string) (*CLIOptions, error) {
+	// 先頭の引数がオプションでなければサブコマンドとして扱う
+	var command string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		command = args[0]
+		args = args[1:]
+		if command != CommandCheck && command != CommandUpdate {
+			return nil, fmt.Errorf("Unknown command: %s", command)
+		}
+	}
+
 	// 第1段階: フラグとその値を連想配列に分類
 	flags := make(map[string][]string)
 	var currentFlag string
@@ -71,6 +115,7 @@ func ParseFlags(_ interface{}, args []string) (*CLIOptions, error) {
 
 	// 第2段階: 連想配列から必要なフラグの値を取り出してCLIOptionsを構築
 	opts := NewCLIOptions()
+	opts.Command = command
 
 	if values, ok := flags[InputFilesFlag]; ok {
 		opts.InputFiles = values
@@ -102,18 +147,54 @@ func ParseFlags(_ interface{}, args []string) (*CLIOptions, error) {
 		}
 	}
 
+	if values, ok := flags[FormatFlag]; ok {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("Output format not specified: %s", FormatFlag)
+		}
+		opts.OutputFormat = values[0]
+	}
+	if values, ok := flags[FormatFlagLong]; ok {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("Output format not specified: %s", FormatFlagLong)
+		}
+		opts.OutputFormat = values[0]
+	}
+
+	if _, ok := flags[StdinFlag]; ok {
+		opts.Stdin = true
+	}
+	if _, ok := flags[StdinFlagLong]; ok {
+		opts.Stdin = true
+	}
+
+	if _, ok := flags[OnceFlagLong]; ok {
+		opts.Once = true
+	}
+
+	if _, ok := flags[UpdateLockFlagLong]; ok {
+		opts.UpdateLock = true
+	}
+
+	if _, ok := flags[OfflineFlagLong]; ok {
+		opts.Offline = true
+	}
+
 	// Flag validation
 	for flag := range flags {
 		if flag != InputFilesFlag && flag != InputFilesFlagLong &&
 			flag != OutputFileFlag && flag != OutputFileFlagLong &&
-			flag != ConfigFileFlag && flag != ConfigFileFlagLong {
+			flag != ConfigFileFlag && flag != ConfigFileFlagLong &&
+			flag != StdinFlag && flag != StdinFlagLong &&
+			flag != FormatFlag && flag != FormatFlagLong &&
+			flag != OnceFlagLong && flag != UpdateLockFlagLong &&
+			flag != OfflineFlagLong {
 			return nil, fmt.Errorf("Unknown option: %s", flag)
 		}
 	}
 
 	// Required flag validation when no config file is specified
 	if opts.ConfigFile == "" {
-		if len(opts.InputFiles) == 0 {
+		if len(opts.InputFiles) == 0 && !opts.Stdin {
 			return nil, fmt.Errorf("Configuration file wampa.json not found. Please specify -i and -o options or create a configuration file.")
 		}
 		if opts.OutputFile == "" {
@@ -127,8 +208,10 @@ func ParseFlags(_ interface{}, args []string) (*CLIOptions, error) {
 // LoadWithCLIOptions creates a new Config from CLI options
 func LoadWithCLIOptions(opts *CLIOptions) (*Config, error) {
 	config := &Config{
-		InputFiles: opts.InputFiles,
-		OutputFile: opts.OutputFile,
+		InputFiles:   opts.InputFiles,
+		OutputFile:   opts.OutputFile,
+		Stdin:        opts.Stdin,
+		OutputFormat: opts.OutputFormat,
 	}
 
 	if err := config.Validate(); err != nil {
@@ -57,6 +57,20 @@ func TestLoadWithCLIOptions(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "stdin without input files",
+			opts: &CLIOptions{
+				InputFiles: []string{},
+				OutputFile: "output.md",
+				Stdin:      true,
+			},
+			want: &Config{
+				InputFiles: []string{},
+				OutputFile: "output.md",
+				Stdin:      true,
+			},
+			wantErr: false,
+		},
 		{
 			name: "empty output file",
 			opts: &CLIOptions{
@@ -143,6 +157,94 @@ func TestParseFlags(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "stdin flag",
+			args: []string{"-s", "-i", "input.md", "-o", "output.md"},
+			want: &CLIOptions{
+				InputFiles: []string{"input.md"},
+				OutputFile: "output.md",
+				ConfigFile: "wampa.json",
+				Stdin:      true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "long stdin flag",
+			args: []string{"--stdin", "-o", "output.md"},
+			want: &CLIOptions{
+				InputFiles: []string{},
+				OutputFile: "output.md",
+				ConfigFile: "wampa.json",
+				Stdin:      true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "output format",
+			args: []string{"-i", "input.md", "-o", "output.md", "--format", "xml"},
+			want: &CLIOptions{
+				InputFiles:   []string{"input.md"},
+				OutputFile:   "output.md",
+				ConfigFile:   "wampa.json",
+				OutputFormat: "xml",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "missing output format",
+			args:    []string{"-i", "input.md", "-o", "output.md", "-f"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "once flag",
+			args: []string{"-i", "input.md", "-o", "output.md", "--once"},
+			want: &CLIOptions{
+				InputFiles: []string{"input.md"},
+				OutputFile: "output.md",
+				ConfigFile: "wampa.json",
+				Once:       true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "check command",
+			args: []string{"check", "-c", "custom.json"},
+			want: &CLIOptions{
+				InputFiles: []string{},
+				ConfigFile: "custom.json",
+				Command:    CommandCheck,
+			},
+			wantErr: false,
+		},
+		{
+			name: "update command with update-lock flag",
+			args: []string{"update", "--update-lock"},
+			want: &CLIOptions{
+				InputFiles: []string{},
+				ConfigFile: "wampa.json",
+				UpdateLock: true,
+				Command:    CommandUpdate,
+			},
+			wantErr: false,
+		},
+		{
+			name: "check command offline",
+			args: []string{"check", "--offline"},
+			want: &CLIOptions{
+				InputFiles: []string{},
+				ConfigFile: "wampa.json",
+				Offline:    true,
+				Command:    CommandCheck,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"deploy"},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "empty arguments",
 			args: []string{},
@@ -182,6 +284,24 @@ func TestParseFlags(t *testing.T) {
 				if got.ConfigFile != tt.want.ConfigFile {
 					t.Errorf("ParseFlags() ConfigFile = %v, want %v", got.ConfigFile, tt.want.ConfigFile)
 				}
+				if got.OutputFormat != tt.want.OutputFormat {
+					t.Errorf("ParseFlags() OutputFormat = %v, want %v", got.OutputFormat, tt.want.OutputFormat)
+				}
+				if got.Stdin != tt.want.Stdin {
+					t.Errorf("ParseFlags() Stdin = %v, want %v", got.Stdin, tt.want.Stdin)
+				}
+				if got.Once != tt.want.Once {
+					t.Errorf("ParseFlags() Once = %v, want %v", got.Once, tt.want.Once)
+				}
+				if got.Command != tt.want.Command {
+					t.Errorf("ParseFlags() Command = %v, want %v", got.Command, tt.want.Command)
+				}
+				if got.UpdateLock != tt.want.UpdateLock {
+					t.Errorf("ParseFlags() UpdateLock = %v, want %v", got.UpdateLock, tt.want.UpdateLock)
+				}
+				if got.Offline != tt.want.Offline {
+					t.Errorf("ParseFlags() Offline = %v, want %v", got.Offline, tt.want.Offline)
+				}
 			}
 		})
 	}
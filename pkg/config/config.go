@@ -1,11 +1,74 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toms74209200/wampa/pkg/auth"
+	"github.com/toms74209200/wampa/pkg/glob"
+	"github.com/toms74209200/wampa/pkg/httpclient"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+// DefaultRemotePollInterval is the interval between checks of remote input files
+const DefaultRemotePollInterval = time.Minute
+
+// DefaultDebounce is the time to wait for further local changes before rebuilding outputs
+const DefaultDebounce = 100 * time.Millisecond
+
+// DefaultFetchConcurrency is the number of remote input files fetched in parallel
+const DefaultFetchConcurrency = 4
+
+// Ways of handling literal input files that do not exist
+const (
+	// MissingOmit leaves missing input files out of the output
+	MissingOmit = "omit"
+	// MissingPlaceholder keeps a section with placeholder content for missing input files
+	MissingPlaceholder = "placeholder"
+)
+
+// Ways of detecting changes to local input files
+const (
+	// ChangeDetectionMTime compares modification times
+	ChangeDetectionMTime = "mtime"
+	// ChangeDetectionContent compares sizes and content hashes
+	ChangeDetectionContent = "content"
+)
 
 // Config represents the application configuration
 type Config struct {
 	InputFiles []string `json:"input_files"`
 	OutputFile string   `json:"output_file"`
+	// OutputFormat selects a registered output format; empty means the default format
+	OutputFormat string `json:"output_format,omitempty"`
+	// Template is an inline text/template used by the template output format
+	Template string `json:"template,omitempty"`
+	// TemplateFile is the path of a text/template file used by the template output format
+	TemplateFile string `json:"template_file,omitempty"`
+	// PathStyle selects how local paths appear in section markers: relative (the default), base or absolute
+	PathStyle string `json:"path_style,omitempty"`
+	// Exclude lists gitignore-style patterns removed from glob and directory inputs
+	Exclude []string `json:"exclude,omitempty"`
+	// Debounce is the time to wait for further changes before rebuilding outputs, e.g. "200ms"; "0s" rebuilds immediately
+	Debounce string `json:"debounce,omitempty"`
+	// ChangeDetection selects how changes to local files are detected: mtime or content
+	ChangeDetection string `json:"change_detection,omitempty"`
+	// MissingInputs selects how input files that do not exist appear in outputs: omit or placeholder
+	MissingInputs string `json:"missing_inputs,omitempty"`
+	// RemotePollInterval is the default interval for checking remote files, e.g. "5m"
+	RemotePollInterval string `json:"remote_poll_interval,omitempty"`
+	// Outputs lists additional output files, each with its own format and inputs
+	Outputs []Output `json:"outputs,omitempty"`
+	// Remotes holds per-URL settings for remote input files
+	Remotes map[string]RemoteOptions `json:"remotes,omitempty"`
+	// Hosts holds per-host settings for remote input files, keyed by host name or host:port
+	Hosts map[string]HostOptions `json:"hosts,omitempty"`
+	// HTTP holds timeouts and retry settings for fetching remote input files
+	HTTP HTTPOptions `json:"http"`
+	// Stdin indicates that content is also read from standard input.
+	// It can only be enabled from the command line.
+	Stdin bool `json:"-"`
 }
 
 // Validate checks if the configuration is valid
@@ -14,7 +77,7 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("configuration is nil")
 	}
 
-	if len(c.InputFiles) == 0 {
+	if len(c.InputFiles) == 0 && !c.Stdin && len(c.Outputs) == 0 {
 		return fmt.Errorf("Configuration file wampa.json not found. Please specify -i and -o options or create a configuration file.")
 	}
 
@@ -24,9 +87,293 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	if c.OutputFile == "" {
+	if c.OutputFile == "" && len(c.Outputs) == 0 {
 		return fmt.Errorf("Output file not specified. Please specify -o option or create a configuration file.")
 	}
 
+	// Top-level format settings are checked even when every output overrides them
+	if err := validateFormat(Output{
+		OutputFormat: c.OutputFormat,
+		Template:     c.Template,
+		TemplateFile: c.TemplateFile,
+		PathStyle:    c.PathStyle,
+	}); err != nil {
+		return err
+	}
+	if err := c.validateOutputs(); err != nil {
+		return err
+	}
+
+	for i, pattern := range c.Exclude {
+		if pattern == "" {
+			return fmt.Errorf("exclude[%d] is empty", i)
+		}
+		if err := glob.ValidatePattern(pattern); err != nil {
+			return fmt.Errorf("exclude[%d]: invalid pattern %q: %w", i, pattern, err)
+		}
+	}
+
+	if c.Debounce != "" {
+		if _, err := parseDebounce(c.Debounce); err != nil {
+			return fmt.Errorf("debounce: %w", err)
+		}
+	}
+
+	switch c.ChangeDetection {
+	case "", ChangeDetectionMTime, ChangeDetectionContent:
+	default:
+		return fmt.Errorf("change_detection: unknown value %q (want %q or %q)", c.ChangeDetection, ChangeDetectionMTime, ChangeDetectionContent)
+	}
+
+	switch c.MissingInputs {
+	case "", MissingOmit, MissingPlaceholder:
+	default:
+		return fmt.Errorf("missing_inputs: unknown value %q (want %q or %q)", c.MissingInputs, MissingOmit, MissingPlaceholder)
+	}
+
+	if c.RemotePollInterval != "" {
+		if _, err := parseDuration(c.RemotePollInterval); err != nil {
+			return fmt.Errorf("remote_poll_interval: %w", err)
+		}
+	}
+	for url, opts := range c.Remotes {
+		if opts.PollInterval != "" {
+			if _, err := parseDuration(opts.PollInterval); err != nil {
+				return fmt.Errorf("remotes[%q].poll_interval: %w", url, err)
+			}
+		}
+		if err := validateHeaders(opts.Headers); err != nil {
+			return fmt.Errorf("remotes[%q].headers: %w", url, err)
+		}
+	}
+	for host, opts := range c.Hosts {
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			return fmt.Errorf("hosts: invalid host %q", host)
+		}
+		if err := validateHeaders(opts.Headers); err != nil {
+			return fmt.Errorf("hosts[%q].headers: %w", host, err)
+		}
+	}
+	if err := c.HTTP.validate(); err != nil {
+		return fmt.Errorf("http.%w", err)
+	}
+
 	return nil
 }
+
+// Format returns the effective output format of output_file.
+// A configured template selects the template format unless another format is set explicitly.
+func (c *Config) Format() string {
+	return Output{OutputFormat: c.OutputFormat, Template: c.Template, TemplateFile: c.TemplateFile}.Format()
+}
+
+// DebounceWindow returns the time to wait for further changes before rebuilding outputs
+func (c *Config) DebounceWindow() time.Duration {
+	if c.Debounce != "" {
+		if d, err := parseDebounce(c.Debounce); err == nil {
+			return d
+		}
+	}
+	return DefaultDebounce
+}
+
+// parseDebounce parses a debounce window, which may be zero
+func parseDebounce(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative: %q", s)
+	}
+	return d, nil
+}
+
+// WatcherOptions returns the options for watching local files
+func (c *Config) WatcherOptions() watcher.Options {
+	return watcher.Options{CompareContent: c.ChangeDetection == ChangeDetectionContent}
+}
+
+// PlaceholderForMissing reports whether missing input files are shown with placeholder content instead of being omitted
+func (c *Config) PlaceholderForMissing() bool {
+	return c.MissingInputs == MissingPlaceholder
+}
+
+// RemoteOptions represents settings for a single remote input file
+type RemoteOptions struct {
+	// PollInterval overrides RemotePollInterval for this URL
+	PollInterval string `json:"poll_interval,omitempty"`
+	// Headers are sent with requests for this URL; values may reference ${ENV_VAR}
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// HostOptions represents settings for all remote input files on a host
+type HostOptions struct {
+	// Headers are sent with requests to this host; values may reference ${ENV_VAR}
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// URLHeaders returns the headers configured for each remote URL
+func (c *Config) URLHeaders() map[string]map[string]string {
+	headers := make(map[string]map[string]string)
+	for url, opts := range c.Remotes {
+		if len(opts.Headers) > 0 {
+			headers[url] = opts.Headers
+		}
+	}
+	return headers
+}
+
+// HostHeaders returns the headers configured for each host
+func (c *Config) HostHeaders() map[string]map[string]string {
+	headers := make(map[string]map[string]string)
+	for host, opts := range c.Hosts {
+		if len(opts.Headers) > 0 {
+			headers[host] = opts.Headers
+		}
+	}
+	return headers
+}
+
+// validateHeaders checks header names and the syntax of variable references in values.
+// Whether referenced variables are set is checked when the headers are used.
+func validateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, " \t:\r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if _, _, err := auth.ExpandEnv(value, func(string) string { return "set" }); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// HTTPOptions represents timeouts and retry settings for remote requests
+type HTTPOptions struct {
+	// ConnectTimeout limits establishing a connection, e.g. "10s"
+	ConnectTimeout string `json:"connect_timeout,omitempty"`
+	// ReadTimeout limits waiting for the response headers and for each read of the body, e.g. "30s"
+	ReadTimeout string `json:"read_timeout,omitempty"`
+	// Retries is the number of retries after a failed attempt; 0 disables retrying
+	Retries *int `json:"retries,omitempty"`
+	// RetryBackoff is the delay before the first retry, e.g. "500ms"
+	RetryBackoff string `json:"retry_backoff,omitempty"`
+	// MaxBackoff caps the delay between attempts, including Retry-After, e.g. "30s"
+	MaxBackoff string `json:"max_backoff,omitempty"`
+	// Concurrency is the number of remote files fetched in parallel
+	Concurrency *int `json:"concurrency,omitempty"`
+	// TLS holds certificate settings for HTTPS requests
+	TLS TLSOptions `json:"tls"`
+	// Proxy is the proxy URL for all requests, or "direct" to bypass proxies.
+	// When empty, HTTPS_PROXY, HTTP_PROXY and NO_PROXY are used.
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// TLSOptions represents certificate settings for HTTPS requests
+type TLSOptions struct {
+	// CAFile is a PEM bundle of certificate authorities trusted in addition to the system roots
+	CAFile string `json:"ca_file,omitempty"`
+	// CertFile is a PEM client certificate used for mutual TLS
+	CertFile string `json:"cert_file,omitempty"`
+	// KeyFile is the PEM private key of CertFile
+	KeyFile string `json:"key_file,omitempty"`
+	// InsecureSkipVerify disables server certificate verification
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+// validate checks the durations and the retry count
+func (o HTTPOptions) validate() error {
+	durations := []struct {
+		name  string
+		value string
+	}{
+		{"connect_timeout", o.ConnectTimeout},
+		{"read_timeout", o.ReadTimeout},
+		{"retry_backoff", o.RetryBackoff},
+		{"max_backoff", o.MaxBackoff},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if _, err := parseDuration(d.value); err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+	}
+	if o.Retries != nil && *o.Retries < 0 {
+		return fmt.Errorf("retries must not be negative: %d", *o.Retries)
+	}
+	if o.Concurrency != nil && *o.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be positive: %d", *o.Concurrency)
+	}
+	if (o.TLS.CertFile == "") != (o.TLS.KeyFile == "") {
+		return fmt.Errorf("tls: cert_file and key_file must be specified together")
+	}
+	if o.Proxy != "" && o.Proxy != httpclient.ProxyDirect {
+		if _, err := httpclient.ParseProxy(o.Proxy); err != nil {
+			return fmt.Errorf("proxy: %w", err)
+		}
+	}
+	return nil
+}
+
+// FetchConcurrency returns the number of remote files fetched in parallel
+func (c *Config) FetchConcurrency() int {
+	if c.HTTP.Concurrency != nil {
+		return *c.HTTP.Concurrency
+	}
+	return DefaultFetchConcurrency
+}
+
+// ClientOptions returns the HTTP client options, using defaults for unset values
+func (c *Config) ClientOptions() httpclient.Options {
+	opts := httpclient.DefaultOptions()
+	set := func(target *time.Duration, value string) {
+		if d, err := parseDuration(value); err == nil {
+			*target = d
+		}
+	}
+	set(&opts.ConnectTimeout, c.HTTP.ConnectTimeout)
+	set(&opts.ReadTimeout, c.HTTP.ReadTimeout)
+	set(&opts.Backoff, c.HTTP.RetryBackoff)
+	set(&opts.MaxBackoff, c.HTTP.MaxBackoff)
+	if c.HTTP.Retries != nil {
+		opts.Retries = *c.HTTP.Retries
+	}
+	opts.TLS = httpclient.TLSOptions{
+		CAFile:             c.HTTP.TLS.CAFile,
+		CertFile:           c.HTTP.TLS.CertFile,
+		KeyFile:            c.HTTP.TLS.KeyFile,
+		InsecureSkipVerify: c.HTTP.TLS.InsecureSkipVerify,
+	}
+	opts.Proxy = c.HTTP.Proxy
+	return opts
+}
+
+// PollInterval returns the interval for checking the given remote URL
+func (c *Config) PollInterval(url string) time.Duration {
+	if opts, ok := c.Remotes[url]; ok && opts.PollInterval != "" {
+		if d, err := parseDuration(opts.PollInterval); err == nil {
+			return d
+		}
+	}
+	if c.RemotePollInterval != "" {
+		if d, err := parseDuration(c.RemotePollInterval); err == nil {
+			return d
+		}
+	}
+	return DefaultRemotePollInterval
+}
+
+// parseDuration parses a positive duration string
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive: %q", s)
+	}
+	return d, nil
+}
@@ -3,7 +3,11 @@
 package config
 
 import (
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/toms74209200/wampa/pkg/httpclient"
 )
 
 // TestConfig_Validate is a small test that validates config validation
@@ -29,6 +33,303 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "stdin without input files",
+			config: &Config{
+				InputFiles: []string{},
+				OutputFile: "output.md",
+				Stdin:      true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid output format",
+			config: &Config{
+				InputFiles:   []string{"file1.md"},
+				OutputFile:   "output.md",
+				OutputFormat: "xml",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown output format",
+			config: &Config{
+				InputFiles:   []string{"file1.md"},
+				OutputFile:   "output.md",
+				OutputFormat: "yaml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "inline template implies template format",
+			config: &Config{
+				InputFiles: []string{"file1.md"},
+				OutputFile: "output.md",
+				Template:   "{{range .Sections}}{{.Content}}{{end}}",
+			},
+			wantErr: false,
+		},
+		{
+			name: "template format without template",
+			config: &Config{
+				InputFiles:   []string{"file1.md"},
+				OutputFile:   "output.md",
+				OutputFormat: "template",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid inline template",
+			config: &Config{
+				InputFiles: []string{"file1.md"},
+				OutputFile: "output.md",
+				Template:   "{{range .Sections}}",
+			},
+			wantErr: true,
+		},
+		{
+			name: "both template and template file",
+			config: &Config{
+				InputFiles:   []string{"file1.md"},
+				OutputFile:   "output.md",
+				Template:     "{{.Count}}",
+				TemplateFile: "output.tmpl",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid exclude patterns",
+			config: &Config{
+				InputFiles: []string{"docs/**/*.md"},
+				OutputFile: "output.md",
+				Exclude:    []string{"*.draft.md", "vendor/"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "malformed exclude pattern",
+			config: &Config{
+				InputFiles: []string{"docs/**/*.md"},
+				OutputFile: "output.md",
+				Exclude:    []string{"[.md"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid remote poll intervals",
+			config: &Config{
+				InputFiles:         []string{"https://example.com/rules.md"},
+				OutputFile:         "output.md",
+				RemotePollInterval: "5m",
+				Remotes: map[string]RemoteOptions{
+					"https://example.com/rules.md": {PollInterval: "30s"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid remote poll interval",
+			config: &Config{
+				InputFiles:         []string{"https://example.com/rules.md"},
+				OutputFile:         "output.md",
+				RemotePollInterval: "often",
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-positive per-URL poll interval",
+			config: &Config{
+				InputFiles: []string{"https://example.com/rules.md"},
+				OutputFile: "output.md",
+				Remotes: map[string]RemoteOptions{
+					"https://example.com/rules.md": {PollInterval: "0s"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative path style",
+			config: &Config{
+				InputFiles: []string{"docs/**/*.md"},
+				OutputFile: "output.md",
+				PathStyle:  "relative",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown path style",
+			config: &Config{
+				InputFiles: []string{"file1.md"},
+				OutputFile: "output.md",
+				PathStyle:  "short",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid remote headers",
+			config: &Config{
+				InputFiles: []string{"https://gitlab.example.com/rules.md"},
+				OutputFile: "output.md",
+				Remotes: map[string]RemoteOptions{
+					"https://gitlab.example.com/rules.md": {Headers: map[string]string{"PRIVATE-TOKEN": "${GITLAB_TOKEN}"}},
+				},
+				Hosts: map[string]HostOptions{
+					"gitea.internal:3000": {Headers: map[string]string{"Authorization": "token ${GITEA_TOKEN}"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid header name",
+			config: &Config{
+				InputFiles: []string{"https://example.com/rules.md"},
+				OutputFile: "output.md",
+				Hosts: map[string]HostOptions{
+					"example.com": {Headers: map[string]string{"Bad Header": "x"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unterminated variable in header",
+			config: &Config{
+				InputFiles: []string{"https://example.com/rules.md"},
+				OutputFile: "output.md",
+				Remotes: map[string]RemoteOptions{
+					"https://example.com/rules.md": {Headers: map[string]string{"Authorization": "Bearer ${TOKEN"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid HTTP options",
+			config: &Config{
+				InputFiles: []string{"https://example.com/rules.md"},
+				OutputFile: "output.md",
+				HTTP:       HTTPOptions{ConnectTimeout: "5s", ReadTimeout: "1m", Retries: intPtr(0), RetryBackoff: "200ms", MaxBackoff: "10s", Concurrency: intPtr(8)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid HTTP timeout",
+			config: &Config{
+				InputFiles: []string{"https://example.com/rules.md"},
+				OutputFile: "output.md",
+				HTTP:       HTTPOptions{ReadTimeout: "forever"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid TLS and proxy options",
+			config: &Config{
+				InputFiles: []string{"https://gitea.internal/rules.md"},
+				OutputFile: "output.md",
+				HTTP: HTTPOptions{
+					TLS:   TLSOptions{CAFile: "ca.pem", CertFile: "client.pem", KeyFile: "client.key"},
+					Proxy: "http://proxy.internal:3128",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "direct proxy",
+			config: &Config{
+				InputFiles: []string{"https://gitea.internal/rules.md"},
+				OutputFile: "output.md",
+				HTTP:       HTTPOptions{Proxy: "direct"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "client certificate without key",
+			config: &Config{
+				InputFiles: []string{"https://gitea.internal/rules.md"},
+				OutputFile: "output.md",
+				HTTP:       HTTPOptions{TLS: TLSOptions{CertFile: "client.pem"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid proxy",
+			config: &Config{
+				InputFiles: []string{"https://gitea.internal/rules.md"},
+				OutputFile: "output.md",
+				HTTP:       HTTPOptions{Proxy: "ftp://proxy.internal"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero HTTP concurrency",
+			config: &Config{
+				InputFiles: []string{"https://example.com/rules.md"},
+				OutputFile: "output.md",
+				HTTP:       HTTPOptions{Concurrency: intPtr(0)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative HTTP retries",
+			config: &Config{
+				InputFiles: []string{"https://example.com/rules.md"},
+				OutputFile: "output.md",
+				HTTP:       HTTPOptions{Retries: intPtr(-1)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero debounce",
+			config: &Config{
+				InputFiles: []string{"file1.md"},
+				OutputFile: "output.md",
+				Debounce:   "0s",
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative debounce",
+			config: &Config{
+				InputFiles: []string{"file1.md"},
+				OutputFile: "output.md",
+				Debounce:   "-1s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "content change detection",
+			config: &Config{
+				InputFiles:      []string{"file1.md"},
+				OutputFile:      "output.md",
+				ChangeDetection: ChangeDetectionContent,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown change detection",
+			config: &Config{
+				InputFiles:      []string{"file1.md"},
+				OutputFile:      "output.md",
+				ChangeDetection: "size",
+			},
+			wantErr: true,
+		},
+		{
+			name: "placeholder for missing inputs",
+			config: &Config{
+				InputFiles:    []string{"file1.md"},
+				OutputFile:    "output.md",
+				MissingInputs: MissingPlaceholder,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown missing inputs mode",
+			config: &Config{
+				InputFiles:    []string{"file1.md"},
+				OutputFile:    "output.md",
+				MissingInputs: "skip",
+			},
+			wantErr: true,
+		},
 		{
 			name: "empty output file",
 			config: &Config{
@@ -48,3 +349,142 @@ func TestConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+// TestConfig_PollInterval tests resolution of remote poll intervals
+func TestConfig_PollInterval(t *testing.T) {
+	const testURL = "https://example.com/rules.md"
+
+	tests := []struct {
+		name   string
+		config *Config
+		want   time.Duration
+	}{
+		{
+			name:   "default interval",
+			config: &Config{},
+			want:   DefaultRemotePollInterval,
+		},
+		{
+			name:   "global interval",
+			config: &Config{RemotePollInterval: "5m"},
+			want:   5 * time.Minute,
+		},
+		{
+			name: "per-URL interval overrides global",
+			config: &Config{
+				RemotePollInterval: "5m",
+				Remotes: map[string]RemoteOptions{
+					testURL: {PollInterval: "30s"},
+				},
+			},
+			want: 30 * time.Second,
+		},
+		{
+			name: "other URL uses global interval",
+			config: &Config{
+				RemotePollInterval: "5m",
+				Remotes: map[string]RemoteOptions{
+					"https://example.com/other.md": {PollInterval: "30s"},
+				},
+			},
+			want: 5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.PollInterval(testURL); got != tt.want {
+				t.Errorf("Config.PollInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// TestConfig_ClientOptions tests resolution of HTTP client options
+func TestConfig_ClientOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		want   httpclient.Options
+	}{
+		{
+			name:   "defaults",
+			config: &Config{},
+			want:   httpclient.DefaultOptions(),
+		},
+		{
+			name: "configured values",
+			config: &Config{HTTP: HTTPOptions{
+				ConnectTimeout: "5s",
+				ReadTimeout:    "1m",
+				Retries:        intPtr(0),
+				RetryBackoff:   "200ms",
+				MaxBackoff:     "10s",
+				TLS:            TLSOptions{CAFile: "ca.pem", CertFile: "client.pem", KeyFile: "client.key", InsecureSkipVerify: true},
+				Proxy:          "http://proxy.internal:3128",
+			}},
+			want: httpclient.Options{
+				ConnectTimeout: 5 * time.Second,
+				ReadTimeout:    time.Minute,
+				Retries:        0,
+				Backoff:        200 * time.Millisecond,
+				MaxBackoff:     10 * time.Second,
+				TLS:            httpclient.TLSOptions{CAFile: "ca.pem", CertFile: "client.pem", KeyFile: "client.key", InsecureSkipVerify: true},
+				Proxy:          "http://proxy.internal:3128",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.ClientOptions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.ClientOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConfig_FetchConcurrency tests resolution of the number of parallel fetches
+func TestConfig_FetchConcurrency(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		want   int
+	}{
+		{name: "default", config: &Config{}, want: DefaultFetchConcurrency},
+		{name: "configured", config: &Config{HTTP: HTTPOptions{Concurrency: intPtr(8)}}, want: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.FetchConcurrency(); got != tt.want {
+				t.Errorf("Config.FetchConcurrency() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConfig_DebounceWindow tests resolution of the debounce window
+func TestConfig_DebounceWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		want   time.Duration
+	}{
+		{name: "default", config: &Config{}, want: DefaultDebounce},
+		{name: "configured", config: &Config{Debounce: "250ms"}, want: 250 * time.Millisecond},
+		{name: "disabled", config: &Config{Debounce: "0s"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.DebounceWindow(); got != tt.want {
+				t.Errorf("Config.DebounceWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,33 +13,44 @@ func Parse(data []byte) (*Config, error) {
 		return nil, fmt.Errorf("invalid JSON format: %w", err)
 	}
 
-	// 必須フィールドの存在チェック
+	// 必須フィールドの存在チェック（outputsがある場合は省略可能）
+	outputs, hasOutputs := jsonMap["outputs"]
+	if hasOutputs {
+		if _, ok := outputs.([]interface{}); !ok {
+			return nil, fmt.Errorf("outputs must be an array")
+		}
+	}
+
 	inputFiles, hasInputFiles := jsonMap["input_files"]
-	if !hasInputFiles {
+	if !hasInputFiles && !hasOutputs {
 		return nil, fmt.Errorf("missing required field: input_files")
 	}
 
 	outputFile, hasOutputFile := jsonMap["output_file"]
-	if !hasOutputFile {
+	if !hasOutputFile && !hasOutputs {
 		return nil, fmt.Errorf("missing required field: output_file")
 	}
 
-	// input_filesの型チェック
-	inputFilesSlice, ok := inputFiles.([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("input_files must be an array")
-	}
+	if hasInputFiles {
+		// input_filesの型チェック
+		inputFilesSlice, ok := inputFiles.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("input_files must be an array")
+		}
 
-	// input_filesの要素が全て文字列かチェック
-	for i, file := range inputFilesSlice {
-		if _, ok := file.(string); !ok {
-			return nil, fmt.Errorf("input_files[%d] must be a string", i)
+		// input_filesの要素が全て文字列かチェック
+		for i, file := range inputFilesSlice {
+			if _, ok := file.(string); !ok {
+				return nil, fmt.Errorf("input_files[%d] must be a string", i)
+			}
 		}
 	}
 
-	// output_fileの型チェック
-	if _, ok := outputFile.(string); !ok {
-		return nil, fmt.Errorf("output_file must be a string")
+	if hasOutputFile {
+		// output_fileの型チェック
+		if _, ok := outputFile.(string); !ok {
+			return nil, fmt.Errorf("output_file must be a string")
+		}
 	}
 
 	// 実際の構造体へのパース
@@ -184,6 +184,27 @@ func TestParse(t *testing.T) {
 			},
 			wantErr: false, // 余分なキーは無視する
 		},
+		{
+			name:  "outputs without top-level fields",
+			input: []byte(`{"outputs":[{"path":"CLAUDE.md","input_files":["spec.md"]},{"path":"AGENTS.md","input_files":["spec.md"],"format":"xml"}]}`),
+			want: &Config{
+				Outputs: []Output{
+					{Path: "CLAUDE.md", InputFiles: []string{"spec.md"}},
+					{Path: "AGENTS.md", InputFiles: []string{"spec.md"}, OutputFormat: "xml"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "invalid type - outputs is object",
+			input:   []byte(`{"input_files":["file1.md"],"outputs":{"path":"output.md"}}`),
+			wantErr: true,
+		},
+		{
+			name:    "outputs without inputs",
+			input:   []byte(`{"outputs":[{"path":"CLAUDE.md"}]}`),
+			wantErr: true,
+		},
 		{
 			name:    "empty object",
 			input:   []byte(`{}`),
@@ -226,6 +247,15 @@ func TestParse(t *testing.T) {
 				if got.OutputFile != tt.want.OutputFile {
 					t.Errorf("Parse() OutputFile = %v, want %v", got.OutputFile, tt.want.OutputFile)
 				}
+				if len(got.Outputs) != len(tt.want.Outputs) {
+					t.Errorf("Parse() Outputs length = %v, want %v", len(got.Outputs), len(tt.want.Outputs))
+					return
+				}
+				for i, o := range got.Outputs {
+					if o.Path != tt.want.Outputs[i].Path || o.OutputFormat != tt.want.Outputs[i].OutputFormat {
+						t.Errorf("Parse() Outputs[%d] = %+v, want %+v", i, o, tt.want.Outputs[i])
+					}
+				}
 			}
 		})
 	}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/toms74209200/wampa/pkg/formatter"
+)
+
+// Output represents a single output file and the inputs combined into it.
+// Unset fields are inherited from the top-level configuration.
+type Output struct {
+	// Path is the path of the output file
+	Path string `json:"path"`
+	// OutputFormat selects a registered output format for this output
+	OutputFormat string `json:"format,omitempty"`
+	// Template is an inline text/template used by the template output format
+	Template string `json:"template,omitempty"`
+	// TemplateFile is the path of a text/template file used by the template output format
+	TemplateFile string `json:"template_file,omitempty"`
+	// PathStyle selects how local paths appear in section markers
+	PathStyle string `json:"path_style,omitempty"`
+	// InputFiles replaces the top-level input files for this output
+	InputFiles []string `json:"input_files,omitempty"`
+	// ExtraInputs are appended to the input files of this output
+	ExtraInputs []string `json:"extra_inputs,omitempty"`
+}
+
+// Format returns the effective output format of the output.
+// A configured template selects the template format unless another format is set explicitly.
+func (o Output) Format() string {
+	if o.OutputFormat != "" {
+		return o.OutputFormat
+	}
+	if o.Template != "" || o.TemplateFile != "" {
+		return formatter.FormatTemplate
+	}
+	return formatter.DefaultFormat
+}
+
+// Targets returns every output file with its effective settings.
+// output_file comes first when set, followed by the entries of outputs.
+// The returned outputs have their extra inputs merged into InputFiles.
+func (c *Config) Targets() []Output {
+	var targets []Output
+	if c.OutputFile != "" {
+		targets = append(targets, Output{
+			Path:         c.OutputFile,
+			OutputFormat: c.OutputFormat,
+			Template:     c.Template,
+			TemplateFile: c.TemplateFile,
+			PathStyle:    c.PathStyle,
+			InputFiles:   c.InputFiles,
+		})
+	}
+
+	for _, o := range c.Outputs {
+		target := Output{
+			Path:         o.Path,
+			OutputFormat: o.OutputFormat,
+			Template:     o.Template,
+			TemplateFile: o.TemplateFile,
+			PathStyle:    o.PathStyle,
+		}
+		if o.OutputFormat == "" && o.Template == "" && o.TemplateFile == "" {
+			target.OutputFormat = c.OutputFormat
+			target.Template = c.Template
+			target.TemplateFile = c.TemplateFile
+		}
+		if target.PathStyle == "" {
+			target.PathStyle = c.PathStyle
+		}
+
+		inputs := o.InputFiles
+		if len(inputs) == 0 {
+			inputs = c.InputFiles
+		}
+		target.InputFiles = append(append([]string(nil), inputs...), o.ExtraInputs...)
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// OutputFiles returns the paths of all output files
+func (c *Config) OutputFiles() []string {
+	targets := c.Targets()
+	files := make([]string, 0, len(targets))
+	for _, target := range targets {
+		files = append(files, target.Path)
+	}
+	return files
+}
+
+// validateOutputs checks the entries of outputs and their effective settings
+func (c *Config) validateOutputs() error {
+	for i, o := range c.Outputs {
+		if o.Path == "" {
+			return fmt.Errorf("outputs[%d].path is empty", i)
+		}
+		for j, file := range o.InputFiles {
+			if file == "" {
+				return fmt.Errorf("outputs[%d].input_files[%d] is empty", i, j)
+			}
+		}
+		for j, file := range o.ExtraInputs {
+			if file == "" {
+				return fmt.Errorf("outputs[%d].extra_inputs[%d] is empty", i, j)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, target := range c.Targets() {
+		key := filepath.Clean(target.Path)
+		if seen[key] {
+			return fmt.Errorf("output file %s is specified more than once", target.Path)
+		}
+		seen[key] = true
+
+		if len(target.InputFiles) == 0 && !c.Stdin {
+			return fmt.Errorf("output file %s has no input files", target.Path)
+		}
+		if err := validateFormat(target); err != nil {
+			return fmt.Errorf("output file %s: %w", target.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// validateFormat checks the format, template and path style of an output
+func validateFormat(o Output) error {
+	if o.Template != "" && o.TemplateFile != "" {
+		return fmt.Errorf("template and template_file cannot both be specified")
+	}
+	switch format := o.Format(); format {
+	case formatter.FormatTemplate:
+		if o.Template == "" && o.TemplateFile == "" {
+			return fmt.Errorf("output_format %q requires template or template_file", format)
+		}
+		if o.Template != "" {
+			if _, err := formatter.NewTemplateFormatter(o.Template); err != nil {
+				return fmt.Errorf("template: %w", err)
+			}
+		}
+	default:
+		if _, err := formatter.New(format); err != nil {
+			return fmt.Errorf("output_format: %w", err)
+		}
+	}
+
+	if _, err := formatter.ParsePathStyle(o.PathStyle); err != nil {
+		return fmt.Errorf("path_style: %w", err)
+	}
+	return nil
+}
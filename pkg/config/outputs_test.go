@@ -0,0 +1,161 @@
+//go:build small
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestConfig_Targets tests resolution of effective output settings
+func TestConfig_Targets(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		want   []Output
+	}{
+		{
+			name: "output_file only",
+			config: &Config{
+				InputFiles:   []string{"spec.md"},
+				OutputFile:   "output.md",
+				OutputFormat: "xml",
+			},
+			want: []Output{
+				{Path: "output.md", OutputFormat: "xml", InputFiles: []string{"spec.md"}},
+			},
+		},
+		{
+			name: "outputs inherit top-level settings",
+			config: &Config{
+				InputFiles:   []string{"spec.md"},
+				OutputFormat: "xml",
+				PathStyle:    "relative",
+				Outputs: []Output{
+					{Path: "CLAUDE.md"},
+					{Path: "AGENTS.md", OutputFormat: "fenced"},
+				},
+			},
+			want: []Output{
+				{Path: "CLAUDE.md", OutputFormat: "xml", PathStyle: "relative", InputFiles: []string{"spec.md"}},
+				{Path: "AGENTS.md", OutputFormat: "fenced", PathStyle: "relative", InputFiles: []string{"spec.md"}},
+			},
+		},
+		{
+			name: "input subset and extra inputs",
+			config: &Config{
+				InputFiles: []string{"spec.md", "rules.md"},
+				OutputFile: "CLAUDE.md",
+				Outputs: []Output{
+					{Path: ".cursorrules", InputFiles: []string{"rules.md"}},
+					{Path: "AGENTS.md", ExtraInputs: []string{"agents.md"}},
+				},
+			},
+			want: []Output{
+				{Path: "CLAUDE.md", InputFiles: []string{"spec.md", "rules.md"}},
+				{Path: ".cursorrules", InputFiles: []string{"rules.md"}},
+				{Path: "AGENTS.md", InputFiles: []string{"spec.md", "rules.md", "agents.md"}},
+			},
+		},
+		{
+			name: "template overrides inherited format",
+			config: &Config{
+				InputFiles:   []string{"spec.md"},
+				OutputFormat: "xml",
+				Outputs: []Output{
+					{Path: "CLAUDE.md", Template: "{{.Count}}"},
+				},
+			},
+			want: []Output{
+				{Path: "CLAUDE.md", Template: "{{.Count}}", InputFiles: []string{"spec.md"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Targets(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.Targets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConfig_ValidateOutputs tests validation of the outputs array
+func TestConfig_ValidateOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr bool
+	}{
+		{
+			name: "outputs with own inputs",
+			config: &Config{
+				Outputs: []Output{
+					{Path: "CLAUDE.md", InputFiles: []string{"spec.md"}},
+					{Path: "AGENTS.md", InputFiles: []string{"rules.md"}, OutputFormat: "xml"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "output without inputs",
+			config: &Config{
+				Outputs: []Output{
+					{Path: "CLAUDE.md"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty output path",
+			config: &Config{
+				InputFiles: []string{"spec.md"},
+				Outputs: []Output{
+					{Path: ""},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate output path",
+			config: &Config{
+				InputFiles: []string{"spec.md"},
+				OutputFile: "CLAUDE.md",
+				Outputs: []Output{
+					{Path: "./CLAUDE.md"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown output format",
+			config: &Config{
+				InputFiles: []string{"spec.md"},
+				Outputs: []Output{
+					{Path: "CLAUDE.md", OutputFormat: "yaml"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty extra input",
+			config: &Config{
+				InputFiles: []string{"spec.md"},
+				Outputs: []Output{
+					{Path: "CLAUDE.md", ExtraInputs: []string{""}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
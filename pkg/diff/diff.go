@@ -0,0 +1,279 @@
+// Package diff provides line-based unified diffs of text
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+// opKind identifies the kind of a single line edit
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit is a single line operation with the positions in both texts where it applies
+type edit struct {
+	kind opKind
+	a    int
+	b    int
+	line string
+}
+
+// Unified returns a unified diff that turns from into to, or an empty string when they are equal.
+// fromName and toName are used in the file header lines.
+// This is a pure function that can be easily tested
+func Unified(fromName, toName, from, to string, context int) string {
+	if from == to {
+		return ""
+	}
+
+	edits := lineEdits(splitLines(from), splitLines(to))
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(edits, context) {
+		writeHunk(&buf, edits[h[0]:h[1]])
+	}
+	return buf.String()
+}
+
+// splitLines splits text into lines that keep their line terminator.
+// The last line has no terminator when the text does not end with a newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineEdits returns the shortest edit script from a to b using the linear-space variant of the Myers algorithm,
+// so memory grows with the length of the texts rather than with the number of changes
+func lineEdits(a, b []string) []edit {
+	s := &script{a: a, b: b}
+	s.compare(0, len(a), 0, len(b))
+	return deletesFirst(s.edits)
+}
+
+// script collects the edits that turn a into b
+type script struct {
+	a     []string
+	b     []string
+	edits []edit
+}
+
+// compare appends the edits that turn a[aLo:aHi] into b[bLo:bHi].
+// The ranges are split at the middle snake of their shortest edit script until one of them is empty.
+func (s *script) compare(aLo, aHi, bLo, bHi int) {
+	// Lines shared at the start and end need no search
+	for aLo < aHi && bLo < bHi && s.a[aLo] == s.b[bLo] {
+		s.edits = append(s.edits, edit{kind: opEqual, a: aLo, b: bLo, line: s.a[aLo]})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && s.a[aHi-suffix-1] == s.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			s.edits = append(s.edits, edit{kind: opInsert, a: aLo, b: y, line: s.b[y]})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			s.edits = append(s.edits, edit{kind: opDelete, a: x, b: bLo, line: s.a[x]})
+		}
+	default:
+		// Both ranges are non-empty and differ at both ends, so the edit script has at least two edits
+		// and the middle snake splits it into two shorter ones
+		x, y, u, v := s.middleSnake(aLo, aHi, bLo, bHi)
+		s.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			s.edits = append(s.edits, edit{kind: opEqual, a: x, b: y, line: s.a[x]})
+		}
+		s.compare(u, aHi, v, bHi)
+	}
+
+	for i := 0; i < suffix; i++ {
+		s.edits = append(s.edits, edit{kind: opEqual, a: aHi + i, b: bHi + i, line: s.a[aHi+i]})
+	}
+}
+
+// middleSnake finds the snake from (x, y) to (u, v) in the middle of a shortest edit script
+// from a[aLo:aHi] to b[bLo:bHi] by searching from both ends at once.
+// Only the furthest reaching paths of the current round are kept.
+func (s *script) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	max := n + m
+	offset := 2*max + 2
+	// forward holds the furthest x on each diagonal k = x - y from the start,
+	// backward the smallest x on each diagonal from the end, relative to aLo
+	forward := make([]int, 4*max+5)
+	backward := make([]int, 4*max+5)
+	forward[offset+1] = 0
+	backward[offset+delta-1] = n
+
+	for d := 0; d <= (max+1)/2; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && s.a[aLo+u] == s.b[bLo+v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+			if odd && k >= delta-(d-1) && k <= delta+(d-1) && backward[offset+k] <= u {
+				return aLo + x, bLo + y, aLo + u, bLo + v
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			kk := k + delta
+			if k == d || (k != -d && backward[offset+kk-1] < backward[offset+kk+1]) {
+				u = backward[offset+kk-1]
+			} else {
+				u = backward[offset+kk+1] - 1
+			}
+			v = u - kk
+			x, y = u, v
+			for x > 0 && y > 0 && s.a[aLo+x-1] == s.b[bLo+y-1] {
+				x--
+				y--
+			}
+			backward[offset+kk] = x
+			if !odd && kk >= -d && kk <= d && x <= forward[offset+kk] {
+				return aLo + x, bLo + y, aLo + u, bLo + v
+			}
+		}
+	}
+	// Unreachable: the searches always meet by the middle round
+	panic("diff: no middle snake")
+}
+
+// deletesFirst reorders every run of changes so that its deletions come before its insertions,
+// as unified diffs conventionally show them
+func deletesFirst(edits []edit) []edit {
+	for i := 0; i < len(edits); {
+		if edits[i].kind == opEqual {
+			i++
+			continue
+		}
+		end := i
+		var deletes, inserts []edit
+		for ; end < len(edits) && edits[end].kind != opEqual; end++ {
+			if edits[end].kind == opDelete {
+				deletes = append(deletes, edits[end])
+			} else {
+				inserts = append(inserts, edits[end])
+			}
+		}
+
+		a, b := edits[i].a, edits[i].b
+		for j, e := range deletes {
+			edits[i+j] = edit{kind: opDelete, a: a + j, b: b, line: e.line}
+		}
+		a += len(deletes)
+		for j, e := range inserts {
+			edits[i+len(deletes)+j] = edit{kind: opInsert, a: a, b: b + j, line: e.line}
+		}
+		i = end
+	}
+	return edits
+}
+
+// hunks groups changes with their surrounding context into [start, end) ranges of edits.
+// Changes separated by no more than twice the context are merged into one hunk.
+func hunks(edits []edit, context int) [][2]int {
+	var result [][2]int
+	for i := 0; i < len(edits); i++ {
+		if edits[i].kind == opEqual {
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + 1
+		for j := i + 1; j < len(edits); j++ {
+			if edits[j].kind == opEqual {
+				continue
+			}
+			if j-end > 2*context {
+				break
+			}
+			end = j + 1
+		}
+		i = end - 1
+
+		end += context
+		if end > len(edits) {
+			end = len(edits)
+		}
+		result = append(result, [2]int{start, end})
+	}
+	return result
+}
+
+// writeHunk writes a hunk header followed by its lines
+func writeHunk(buf *strings.Builder, edits []edit) {
+	aCount, bCount := 0, 0
+	for _, e := range edits {
+		if e.kind != opInsert {
+			aCount++
+		}
+		if e.kind != opDelete {
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(buf, "@@ -%s +%s @@\n", formatRange(edits[0].a, aCount), formatRange(edits[0].b, bCount))
+	for _, e := range edits {
+		switch e.kind {
+		case opEqual:
+			buf.WriteString(" ")
+		case opDelete:
+			buf.WriteString("-")
+		case opInsert:
+			buf.WriteString("+")
+		}
+		buf.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// formatRange formats the line range of a hunk header.
+// start is the zero-based position of the first line.
+func formatRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
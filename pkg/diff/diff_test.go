@@ -0,0 +1,99 @@
+//go:build small
+
+package diff
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "identical",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			from: "a\nb\nc\n",
+			to:   "a\nB\nc\n",
+			want: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "new file",
+			from: "",
+			to:   "a\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name: "removed content",
+			from: "a\n",
+			to:   "",
+			want: "--- old\n+++ new\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "missing trailing newline",
+			from: "a\nb\n",
+			to:   "a\nb",
+			want: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name: "separate hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:   "X\n2\n3\n4\n5\n6\n7\n8\n9\nY\n",
+			want: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n-1\n+X\n 2\n@@ -9,2 +9,2 @@\n 9\n-10\n+Y\n",
+		},
+		{
+			name: "nearby changes share a hunk",
+			from: "1\n2\n3\n4\n",
+			to:   "X\n2\n3\nY\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n-4\n+Y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("old", "new", tt.from, tt.to, 1); got != tt.want {
+				t.Errorf("Unified() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnified_LargeChange(t *testing.T) {
+	const lines = 5000
+	var from, to strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&from, "old %d\n", i)
+		fmt.Fprintf(&to, "new %d\n", i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	got := Unified("old", "new", from.String(), to.String(), DefaultContext)
+	runtime.ReadMemStats(&after)
+
+	want := fmt.Sprintf("--- old\n+++ new\n@@ -1,%d +1,%d @@\n", lines, lines)
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("Unified() starts with %q, want %q", got[:min(len(got), 80)], want)
+	}
+	if n := strings.Count(got, "\n-old "); n != lines {
+		t.Errorf("Unified() has %d deleted lines, want %d", n, lines)
+	}
+	if n := strings.Count(got, "\n+new "); n != lines {
+		t.Errorf("Unified() has %d inserted lines, want %d", n, lines)
+	}
+	// Memory grows with the length of the texts, not with the number of changes times their length
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+		t.Errorf("Unified() allocated %d MB", allocated>>20)
+	}
+}
@@ -0,0 +1,267 @@
+package formatter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// XMLFormatter wraps each file in a <document path="..."> element
+type XMLFormatter struct{}
+
+// NewXMLFormatter creates a new XMLFormatter
+func NewXMLFormatter() *XMLFormatter {
+	return &XMLFormatter{}
+}
+
+// Format combines multiple file contents into document elements
+func (f *XMLFormatter) Format(files []string, contents map[string]string) (string, error) {
+	return f.FormatSections(NewSections(files, contents))
+}
+
+// FormatSections combines sections into document elements
+func (f *XMLFormatter) FormatSections(sections []Section) (string, error) {
+	return formatStream(f, sections)
+}
+
+// FormatTo writes sections as document elements to w
+func (f *XMLFormatter) FormatTo(w io.Writer, sections []Section) error {
+	return writeParts(w, f, sections)
+}
+
+// WritePart writes a section as a document element to w
+func (f *XMLFormatter) WritePart(w io.Writer, section Section) error {
+	var path bytes.Buffer
+	if err := xml.EscapeText(&path, []byte(displayName(section))); err != nil {
+		return fmt.Errorf("escaping path %s: %w", section.Path, err)
+	}
+	if _, err := io.WriteString(w, `<document path="`+path.String()+`">`+"\n"); err != nil {
+		return err
+	}
+	if err := writeContent(w, section); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n"+`</document>`)
+	return err
+}
+
+// HTMLCommentFormatter precedes each file with an HTML comment holding its path
+type HTMLCommentFormatter struct{}
+
+// NewHTMLCommentFormatter creates a new HTMLCommentFormatter
+func NewHTMLCommentFormatter() *HTMLCommentFormatter {
+	return &HTMLCommentFormatter{}
+}
+
+// Format combines multiple file contents with HTML comment separators
+func (f *HTMLCommentFormatter) Format(files []string, contents map[string]string) (string, error) {
+	return f.FormatSections(NewSections(files, contents))
+}
+
+// FormatSections combines sections with HTML comment separators
+func (f *HTMLCommentFormatter) FormatSections(sections []Section) (string, error) {
+	return formatStream(f, sections)
+}
+
+// FormatTo writes sections with HTML comment separators to w
+func (f *HTMLCommentFormatter) FormatTo(w io.Writer, sections []Section) error {
+	return writeParts(w, f, sections)
+}
+
+// WritePart writes a section preceded by an HTML comment to w
+func (f *HTMLCommentFormatter) WritePart(w io.Writer, section Section) error {
+	// "--" must not appear inside an HTML comment
+	path := strings.ReplaceAll(displayName(section), "--", "-\\-")
+	if _, err := io.WriteString(w, `<!-- filepath: `+path+` -->`+"\n"); err != nil {
+		return err
+	}
+	return writeContent(w, section)
+}
+
+// FencedFormatter places each file in a fenced code block whose info string is its path
+type FencedFormatter struct{}
+
+// NewFencedFormatter creates a new FencedFormatter
+func NewFencedFormatter() *FencedFormatter {
+	return &FencedFormatter{}
+}
+
+// Format combines multiple file contents into fenced code blocks
+func (f *FencedFormatter) Format(files []string, contents map[string]string) (string, error) {
+	return f.FormatSections(NewSections(files, contents))
+}
+
+// FormatSections combines sections into fenced code blocks
+func (f *FencedFormatter) FormatSections(sections []Section) (string, error) {
+	return formatStream(f, sections)
+}
+
+// FormatTo writes sections as fenced code blocks to w
+func (f *FencedFormatter) FormatTo(w io.Writer, sections []Section) error {
+	return writeParts(w, f, sections)
+}
+
+// WritePart writes a section as a fenced code block to w.
+// Content behind Section.Open is read twice, first to choose the fence.
+func (f *FencedFormatter) WritePart(w io.Writer, section Section) error {
+	var runs backtickRuns
+	if err := writeContent(&runs, section); err != nil {
+		return err
+	}
+	fence := runs.fence()
+	if _, err := io.WriteString(w, fence+displayName(section)+"\n"); err != nil {
+		return err
+	}
+	if err := writeContent(w, section); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n"+fence)
+	return err
+}
+
+// backtickRuns records the longest run of backticks written to it
+type backtickRuns struct {
+	longest int
+	run     int
+}
+
+// Write scans p for backticks, continuing a run across writes
+func (b *backtickRuns) Write(p []byte) (int, error) {
+	for _, c := range p {
+		b.scan(c)
+	}
+	return len(p), nil
+}
+
+// WriteString scans s for backticks without copying it
+func (b *backtickRuns) WriteString(s string) (int, error) {
+	for i := 0; i < len(s); i++ {
+		b.scan(s[i])
+	}
+	return len(s), nil
+}
+
+// scan counts c towards the current run of backticks.
+// Scanning bytes is enough because a backtick never occurs inside a multi-byte UTF-8 sequence.
+func (b *backtickRuns) scan(c byte) {
+	if c != '`' {
+		b.run = 0
+		return
+	}
+	b.run++
+	if b.run > b.longest {
+		b.longest = b.run
+	}
+}
+
+// fence returns a backtick fence longer than any backtick run in the content,
+// so that fences inside the content cannot close the block
+func (b *backtickRuns) fence() string {
+	if b.longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", b.longest+1)
+}
+
+// JSONFormatter renders all files as a JSON array of path and content objects
+type JSONFormatter struct{}
+
+// NewJSONFormatter creates a new JSONFormatter
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+// Format combines multiple file contents into a JSON array
+func (f *JSONFormatter) Format(files []string, contents map[string]string) (string, error) {
+	return f.FormatSections(NewSections(files, contents))
+}
+
+// FormatSections combines sections into a JSON array
+func (f *JSONFormatter) FormatSections(sections []Section) (string, error) {
+	return formatStream(f, sections)
+}
+
+// FormatTo writes sections as a JSON array to w.
+// The output matches encoding/json with HTML escaping disabled and an indent of two spaces.
+func (f *JSONFormatter) FormatTo(w io.Writer, sections []Section) error {
+	if len(sections) == 0 {
+		_, err := io.WriteString(w, "[]")
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[\n")
+	for i, section := range sections {
+		if i > 0 {
+			bw.WriteString(",\n")
+		}
+		bw.WriteString("  {\n    \"path\": ")
+		if err := writeJSONString(bw, strings.NewReader(displayName(section))); err != nil {
+			return fmt.Errorf("encoding JSON: %w", err)
+		}
+		bw.WriteString(",\n    \"content\": ")
+		r, err := section.Reader()
+		if err != nil {
+			return err
+		}
+		err = writeJSONString(bw, r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("encoding JSON: %w", err)
+		}
+		bw.WriteString("\n  }")
+	}
+	bw.WriteString("\n]")
+	return bw.Flush()
+}
+
+// writeJSONString writes the content of r to w as a quoted JSON string,
+// escaping it like encoding/json with HTML escaping disabled
+func writeJSONString(w *bufio.Writer, r io.Reader) error {
+	br, ok := r.(io.RuneReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	w.WriteByte('"')
+	for {
+		c, size, err := br.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == '"' || c == '\\':
+			w.WriteByte('\\')
+			w.WriteRune(c)
+		case c == '\b':
+			w.WriteString(`\b`)
+		case c == '\f':
+			w.WriteString(`\f`)
+		case c == '\n':
+			w.WriteString(`\n`)
+		case c == '\r':
+			w.WriteString(`\r`)
+		case c == '\t':
+			w.WriteString(`\t`)
+		case c < 0x20:
+			fmt.Fprintf(w, `\u%04x`, c)
+		case c == utf8.RuneError && size == 1:
+			// Invalid UTF-8 is replaced like encoding/json does
+			w.WriteString(`\ufffd`)
+		case c == '\u2028' || c == '\u2029':
+			// U+2028 and U+2029 are escaped so the output is also valid JavaScript
+			fmt.Fprintf(w, `\u%04x`, c)
+		default:
+			w.WriteRune(c)
+		}
+	}
+	w.WriteByte('"')
+	return nil
+}
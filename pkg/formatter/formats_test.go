@@ -0,0 +1,290 @@
+//go:build small
+
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormats_Format(t *testing.T) {
+	files := []string{"docs/spec.md", "rules.md"}
+	contents := map[string]string{
+		"docs/spec.md": "# 製品仕様\n- 機能A: Xを行う",
+		"rules.md":     "# コーディング規則",
+	}
+
+	tests := []struct {
+		name      string
+		formatter Formatter
+		files     []string
+		contents  map[string]string
+		want      string
+	}{
+		{
+			name:      "XML",
+			formatter: NewXMLFormatter(),
+			files:     files,
+			contents:  contents,
+			want: `<document path="docs/spec.md">
+# 製品仕様
+- 機能A: Xを行う
+</document>
+
+<document path="rules.md">
+# コーディング規則
+</document>`,
+		},
+		{
+			name:      "XML（パスのエスケープ）",
+			formatter: NewXMLFormatter(),
+			files:     []string{`a&"b".md`},
+			contents:  map[string]string{`a&"b".md`: "content"},
+			want: `<document path="a&amp;&#34;b&#34;.md">
+content
+</document>`,
+		},
+		{
+			name:      "HTMLコメント",
+			formatter: NewHTMLCommentFormatter(),
+			files:     files,
+			contents:  contents,
+			want: `<!-- filepath: docs/spec.md -->
+# 製品仕様
+- 機能A: Xを行う
+
+<!-- filepath: rules.md -->
+# コーディング規則`,
+		},
+		{
+			name:      "コードフェンス",
+			formatter: NewFencedFormatter(),
+			files:     files,
+			contents:  contents,
+			want: "```docs/spec.md\n# 製品仕様\n- 機能A: Xを行う\n```\n\n" +
+				"```rules.md\n# コーディング規則\n```",
+		},
+		{
+			name:      "コードフェンス（内容にフェンスを含む）",
+			formatter: NewFencedFormatter(),
+			files:     []string{"example.md"},
+			contents:  map[string]string{"example.md": "```go\nfunc main() {}\n```"},
+			want:      "````example.md\n```go\nfunc main() {}\n```\n````",
+		},
+		{
+			name:      "JSON",
+			formatter: NewJSONFormatter(),
+			files:     files,
+			contents:  contents,
+			want: `[
+  {
+    "path": "docs/spec.md",
+    "content": "# 製品仕様\n- 機能A: Xを行う"
+  },
+  {
+    "path": "rules.md",
+    "content": "# コーディング規則"
+  }
+]`,
+		},
+		{
+			name:      "JSON（空のファイルリスト）",
+			formatter: NewJSONFormatter(),
+			files:     []string{},
+			contents:  map[string]string{},
+			want:      "[]",
+		},
+		{
+			name:      "存在しないコンテンツはスキップ",
+			formatter: NewHTMLCommentFormatter(),
+			files:     []string{"missing.md", "rules.md"},
+			contents:  contents,
+			want: `<!-- filepath: rules.md -->
+# コーディング規則`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.formatter.Format(tt.files, tt.contents)
+			if err != nil {
+				t.Errorf("Format() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Format() got and want differ\nGot:\n%s\n\nWant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		want    Formatter
+		wantErr bool
+	}{
+		{name: "空の場合はデフォルト", format: "", want: &DefaultFormatter{}},
+		{name: "markdown-comment", format: FormatMarkdownComment, want: &DefaultFormatter{}},
+		{name: "xml", format: FormatXML, want: &XMLFormatter{}},
+		{name: "html-comment", format: FormatHTMLComment, want: &HTMLCommentFormatter{}},
+		{name: "fenced", format: FormatFenced, want: &FencedFormatter{}},
+		{name: "json", format: FormatJSON, want: &JSONFormatter{}},
+		{name: "未知のフォーマット", format: "yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotType, wantType := fmt.Sprintf("%T", got), fmt.Sprintf("%T", tt.want); gotType != wantType {
+				t.Errorf("New(%q) = %s, want %s", tt.format, gotType, wantType)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-format", func() Formatter { return NewJSONFormatter() })
+
+	if _, err := New("test-format"); err != nil {
+		t.Errorf("New() error = %v", err)
+	}
+
+	found := false
+	for _, name := range Names() {
+		if name == "test-format" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Names() = %v, want to contain %q", Names(), "test-format")
+	}
+}
+
+// openString returns a Section.Open function reading content and counts the calls in opened
+func openString(content string, opened *int) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		*opened++
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+}
+
+func TestFormats_FormatTo(t *testing.T) {
+	contents := []string{"# 製品仕様\n```go\nfunc main() {}\n```", "# コーディング規則"}
+	inMemory := []Section{
+		{Path: "docs/spec.md", Content: contents[0], Source: SourceLocal},
+		{Path: "rules.md", Content: contents[1], Source: SourceLocal},
+	}
+
+	tmpl, err := NewTemplateFormatter("{{range .Sections}}{{.Path}}: {{.Content}}\n{{end}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatters := map[string]Formatter{
+		"Markdownコメント": NewDefaultFormatter(),
+		"XML":          NewXMLFormatter(),
+		"HTMLコメント":     NewHTMLCommentFormatter(),
+		"コードフェンス":      NewFencedFormatter(),
+		"JSON":         NewJSONFormatter(),
+		"テンプレート":       tmpl,
+	}
+
+	for name, f := range formatters {
+		t.Run(name, func(t *testing.T) {
+			want, err := FormatSections(f, inMemory)
+			if err != nil {
+				t.Fatalf("FormatSections() error = %v", err)
+			}
+
+			// Contents read through Open produce the same output as contents held in memory
+			opened := 0
+			streamed := []Section{
+				{Path: "docs/spec.md", Open: openString(contents[0], &opened), Source: SourceLocal},
+				{Path: "rules.md", Open: openString(contents[1], &opened), Source: SourceLocal},
+			}
+			var buf strings.Builder
+			if err := FormatTo(&buf, f, streamed); err != nil {
+				t.Fatalf("FormatTo() error = %v", err)
+			}
+			if buf.String() != want {
+				t.Errorf("FormatTo() got and want differ\nGot:\n%s\n\nWant:\n%s", buf.String(), want)
+			}
+			if opened == 0 {
+				t.Error("FormatTo() did not open the sections")
+			}
+		})
+	}
+}
+
+func TestFormatTo_OpenError(t *testing.T) {
+	sections := []Section{{
+		Path: "spec.md",
+		Open: func() (io.ReadCloser, error) { return nil, errors.New("permission denied") },
+	}}
+	for _, f := range []Formatter{NewDefaultFormatter(), NewFencedFormatter(), NewJSONFormatter()} {
+		if err := FormatTo(io.Discard, f, sections); err == nil {
+			t.Errorf("FormatTo() with %T expected error but got nil", f)
+		}
+	}
+}
+
+func TestJSONFormatter_Escaping(t *testing.T) {
+	contents := []string{
+		`"quoted" \ back\slash`,
+		"tab\tnewline\nreturn\r\bbell\x07\x1f\x7f",
+		"<html> & 'single'",
+		"line\u2028para\u2029",
+		"invalid \xff\xfe utf-8 \xe3\x81",
+		"replacement � 日本語 🎉",
+	}
+
+	for _, content := range contents {
+		t.Run(fmt.Sprintf("%q", content), func(t *testing.T) {
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			item := struct {
+				Path    string `json:"path"`
+				Content string `json:"content"`
+			}{Path: "a.md", Content: content}
+			if err := encoder.Encode([]any{item}); err != nil {
+				t.Fatal(err)
+			}
+			expected := strings.TrimSuffix(buf.String(), "\n")
+
+			got, err := NewJSONFormatter().FormatSections([]Section{{Path: "a.md", Content: content}})
+			if err != nil {
+				t.Fatalf("FormatSections() error = %v", err)
+			}
+			// Go versions differ in how they replace invalid UTF-8, so only the decoded content is compared for it
+			if utf8.ValidString(content) && got != expected {
+				t.Errorf("FormatSections() = %s, want %s", got, expected)
+			}
+			var decoded, wantDecoded []map[string]string
+			if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+			if err := json.Unmarshal([]byte(expected), &wantDecoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, wantDecoded) {
+				t.Errorf("decoded = %q, want %q", decoded, wantDecoded)
+			}
+		})
+	}
+}
@@ -2,7 +2,8 @@
 package formatter
 
 import (
-	"path/filepath"
+	"io"
+	"strings"
 )
 
 // Formatter defines the interface for combining file contents
@@ -13,7 +14,7 @@ type Formatter interface {
 	Format(files []string, contents map[string]string) (string, error)
 }
 
-// DefaultFormatter implements the standard formatting logic
+// DefaultFormatter implements the standard markdown-comment formatting logic
 type DefaultFormatter struct{}
 
 // NewDefaultFormatter creates a new DefaultFormatter
@@ -23,35 +24,58 @@ func NewDefaultFormatter() *DefaultFormatter {
 
 // Format combines multiple file contents with proper section separators
 func (f *DefaultFormatter) Format(files []string, contents map[string]string) (string, error) {
-	if len(files) == 0 {
-		return "", nil
-	}
+	return f.FormatSections(NewSections(files, contents))
+}
+
+// FormatSections combines sections with proper section separators
+func (f *DefaultFormatter) FormatSections(sections []Section) (string, error) {
+	return formatStream(f, sections)
+}
 
+// FormatTo writes sections with proper section separators to w
+func (f *DefaultFormatter) FormatTo(w io.Writer, sections []Section) error {
 	// 各ファイルの内容を結合（指定された順序を維持）
-	var parts []string
-	for _, file := range files {
-		content, ok := contents[file]
-		if !ok {
-			continue
-		}
-		// 相対パスに変換
-		relPath := filepath.Base(file)
-		parts = append(parts,
-			`[//]: # "filepath: `+relPath+`"`+"\n"+
-				content)
+	return writeParts(w, f, sections)
+}
+
+// WritePart writes a section preceded by its path marker to w
+func (f *DefaultFormatter) WritePart(w io.Writer, section Section) error {
+	if _, err := io.WriteString(w, `[//]: # "filepath: `+displayName(section)+`"`+"\n"); err != nil {
+		return err
 	}
+	return writeContent(w, section)
+}
 
-	return joinParts(parts), nil
+// PartFormatter is implemented by formatters that write each section on its own
+// and separate the sections with blank lines
+type PartFormatter interface {
+	// WritePart writes a single section to w, reading its content as it goes
+	WritePart(w io.Writer, section Section) error
 }
 
-// joinParts joins parts with double newlines
-func joinParts(parts []string) string {
-	if len(parts) == 0 {
-		return ""
+// partSeparator separates rendered sections
+const partSeparator = "\n\n"
+
+// formatStream returns the output that f writes for sections
+func formatStream(f StreamFormatter, sections []Section) (string, error) {
+	var buf strings.Builder
+	if err := f.FormatTo(&buf, sections); err != nil {
+		return "", err
 	}
-	result := parts[0]
-	for i := 1; i < len(parts); i++ {
-		result += "\n\n" + parts[i]
+	return buf.String(), nil
+}
+
+// writeParts writes every section with f to w, separated by blank lines
+func writeParts(w io.Writer, f PartFormatter, sections []Section) error {
+	for i, section := range sections {
+		if i > 0 {
+			if _, err := io.WriteString(w, partSeparator); err != nil {
+				return err
+			}
+		}
+		if err := f.WritePart(w, section); err != nil {
+			return err
+		}
 	}
-	return result
+	return nil
 }
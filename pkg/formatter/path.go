@@ -0,0 +1,103 @@
+package formatter
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// PathStyle controls how local paths are shown in section markers
+type PathStyle string
+
+// Path styles for section markers
+const (
+	// PathStyleBase shows only the file name
+	PathStyleBase PathStyle = "base"
+	// PathStyleRelative shows the path relative to a root directory
+	PathStyleRelative PathStyle = "relative"
+	// PathStyleAbsolute shows the absolute path
+	PathStyleAbsolute PathStyle = "absolute"
+)
+
+// DefaultPathStyle is the path style used when none is configured.
+// Relative paths keep inputs that share a file name apart.
+const DefaultPathStyle = PathStyleRelative
+
+// ParsePathStyle returns the path style with the given name.
+// An empty name selects DefaultPathStyle.
+func ParsePathStyle(name string) (PathStyle, error) {
+	switch style := PathStyle(name); style {
+	case "":
+		return DefaultPathStyle, nil
+	case PathStyleBase, PathStyleRelative, PathStyleAbsolute:
+		return style, nil
+	default:
+		return "", fmt.Errorf("unknown path style %q (available: %s, %s, %s)", name, PathStyleBase, PathStyleRelative, PathStyleAbsolute)
+	}
+}
+
+// DisplayPath returns the name shown for an input in section markers.
+// URLs and standard input keep their full form; local paths are rendered by style,
+// with relative paths resolved against root and separated by slashes.
+// This is a pure function that can be easily tested
+func DisplayPath(path string, style PathStyle, root string) string {
+	if SourceOf(path) != SourceLocal {
+		return path
+	}
+	return localDisplayPath(path, style, root)
+}
+
+// localDisplayPath returns the name shown for a local file in section markers
+func localDisplayPath(path string, style PathStyle, root string) string {
+	switch style {
+	case PathStyleRelative:
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return filepath.ToSlash(path)
+		}
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return filepath.ToSlash(abs)
+		}
+		rel, err := filepath.Rel(absRoot, abs)
+		if err != nil {
+			// No relative path exists, e.g. across Windows volumes
+			return filepath.ToSlash(abs)
+		}
+		return filepath.ToSlash(rel)
+	case PathStyleAbsolute:
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return path
+		}
+		return abs
+	default:
+		return filepath.Base(path)
+	}
+}
+
+// WithPathStyle returns a copy of sections whose names are rendered with the given style.
+// The source of a section decides whether its path is local, so a local file named like StdinPath is still rendered by style.
+// This is a pure function that can be easily tested
+func WithPathStyle(sections []Section, style PathStyle, root string) []Section {
+	result := make([]Section, len(sections))
+	for i, section := range sections {
+		switch section.Source {
+		case SourceLocal:
+			section.Name = localDisplayPath(section.Path, style, root)
+		case SourceRemote, SourceStdin:
+			section.Name = section.Path
+		default:
+			section.Name = DisplayPath(section.Path, style, root)
+		}
+		result[i] = section
+	}
+	return result
+}
+
+// displayName returns the name shown for a section in section markers
+func displayName(s Section) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return DisplayPath(s.Path, DefaultPathStyle, "")
+}
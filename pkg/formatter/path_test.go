@@ -0,0 +1,141 @@
+//go:build small
+
+package formatter
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDisplayPath(t *testing.T) {
+	root := t.TempDir()
+
+	tests := []struct {
+		name  string
+		path  string
+		style PathStyle
+		want  string
+	}{
+		{
+			name:  "ファイル名のみ",
+			path:  filepath.Join(root, "backend", "README.md"),
+			style: PathStyleBase,
+			want:  "README.md",
+		},
+		{
+			name:  "ルートからの相対パス",
+			path:  filepath.Join(root, "backend", "README.md"),
+			style: PathStyleRelative,
+			want:  "backend/README.md",
+		},
+		{
+			name:  "ルート外の相対パス",
+			path:  filepath.Join(filepath.Dir(root), "other", "README.md"),
+			style: PathStyleRelative,
+			want:  "../other/README.md",
+		},
+		{
+			name:  "絶対パス",
+			path:  filepath.Join(root, "frontend", "README.md"),
+			style: PathStyleAbsolute,
+			want:  filepath.Join(root, "frontend", "README.md"),
+		},
+		{
+			name:  "URLはそのまま",
+			path:  "https://example.com/docs/README.md",
+			style: PathStyleBase,
+			want:  "https://example.com/docs/README.md",
+		},
+		{
+			name:  "標準入力はそのまま",
+			path:  StdinPath,
+			style: PathStyleAbsolute,
+			want:  StdinPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DisplayPath(tt.path, tt.style, root); got != tt.want {
+				t.Errorf("DisplayPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePathStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    PathStyle
+		wantErr bool
+	}{
+		{name: "未指定はデフォルト", input: "", want: DefaultPathStyle},
+		{name: "relative", input: "relative", want: PathStyleRelative},
+		{name: "absolute", input: "absolute", want: PathStyleAbsolute},
+		{name: "不明なスタイル", input: "short", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePathStyle(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePathStyle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePathStyle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPathStyle(t *testing.T) {
+	sections := NewSections(
+		[]string{"backend/README.md", "frontend/README.md"},
+		map[string]string{"backend/README.md": "b", "frontend/README.md": "f"},
+	)
+
+	// The default path style tells files with the same name apart
+	got, err := NewDefaultFormatter().FormatSections(sections)
+	if err != nil {
+		t.Fatalf("FormatSections() error = %v", err)
+	}
+	want := "[//]: # \"filepath: backend/README.md\"\nb\n\n[//]: # \"filepath: frontend/README.md\"\nf"
+	if got != want {
+		t.Errorf("FormatSections() = %q, want %q", got, want)
+	}
+
+	got, err = NewDefaultFormatter().FormatSections(WithPathStyle(sections, PathStyleBase, "."))
+	if err != nil {
+		t.Fatalf("FormatSections() error = %v", err)
+	}
+	want = "[//]: # \"filepath: README.md\"\nb\n\n[//]: # \"filepath: README.md\"\nf"
+	if got != want {
+		t.Errorf("FormatSections() = %q, want %q", got, want)
+	}
+	if sections[0].Name != "backend/README.md" {
+		t.Errorf("WithPathStyle() modified its input: %q", sections[0].Name)
+	}
+}
+
+// TestWithPathStyle_Sources tests that sections are named by their source rather than by their path
+func TestWithPathStyle_Sources(t *testing.T) {
+	// A local file named stdin in the working directory
+	local, err := filepath.Abs(StdinPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections := []Section{
+		{Path: StdinPath, Source: SourceStdin},
+		{Path: StdinPath, Source: SourceLocal},
+		{Path: "https://example.com/rules.md", Source: SourceRemote},
+	}
+
+	got := WithPathStyle(sections, PathStyleAbsolute, ".")
+	want := []string{StdinPath, local, "https://example.com/rules.md"}
+	for i, section := range got {
+		if section.Name != want[i] {
+			t.Errorf("WithPathStyle()[%d].Name = %q, want %q", i, section.Name, want[i])
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Built-in output format names
+const (
+	FormatMarkdownComment = "markdown-comment"
+	FormatXML             = "xml"
+	FormatHTMLComment     = "html-comment"
+	FormatFenced          = "fenced"
+	FormatJSON            = "json"
+)
+
+// DefaultFormat is the output format used when none is configured
+const DefaultFormat = FormatMarkdownComment
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]func() Formatter{
+		FormatMarkdownComment: func() Formatter { return NewDefaultFormatter() },
+		FormatXML:             func() Formatter { return NewXMLFormatter() },
+		FormatHTMLComment:     func() Formatter { return NewHTMLCommentFormatter() },
+		FormatFenced:          func() Formatter { return NewFencedFormatter() },
+		FormatJSON:            func() Formatter { return NewJSONFormatter() },
+	}
+)
+
+// Register adds a named output format to the registry, replacing any format with the same name
+func Register(name string, factory func() Formatter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// New creates the Formatter registered under name.
+// An empty name selects DefaultFormat.
+func New(name string) (Formatter, error) {
+	if name == "" {
+		name = DefaultFormat
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(), nil
+}
+
+// Names returns the registered output format names in lexical order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,181 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SourceType identifies where the content of a section comes from
+type SourceType string
+
+// Source types of sections
+const (
+	SourceLocal  SourceType = "local"
+	SourceRemote SourceType = "remote"
+	SourceStdin  SourceType = "stdin"
+)
+
+// StdinPath is the path used for content read from standard input
+const StdinPath = "stdin"
+
+// MissingContent is the placeholder content of a section whose input file does not exist
+const MissingContent = "(file not found)"
+
+// Section represents a single input file in the combined output
+type Section struct {
+	// Path is the input path or URL as configured
+	Path string
+	// Name is the path shown in section markers; the base name of Path is used when empty
+	Name string
+	// Content is the content of the input
+	Content string
+	// Open returns a reader for the content when it is not held in Content.
+	// It may be called more than once; Content is used when Open is nil.
+	Open func() (io.ReadCloser, error)
+	// Source is where the content comes from
+	Source SourceType
+	// ModTime is the last modification time, or the zero time when unknown
+	ModTime time.Time
+	// Size is the size of the content in bytes
+	Size int64
+}
+
+// BaseName returns the last element of the section path
+func (s Section) BaseName() string {
+	return filepath.Base(s.Path)
+}
+
+// Reader returns a reader for the content of the section
+func (s Section) Reader() (io.ReadCloser, error) {
+	if s.Open == nil {
+		return io.NopCloser(strings.NewReader(s.Content)), nil
+	}
+	r, err := s.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", s.Path, err)
+	}
+	return r, nil
+}
+
+// writeContent copies the content of a section to w
+func writeContent(w io.Writer, s Section) error {
+	if s.Open == nil {
+		_, err := io.WriteString(w, s.Content)
+		return err
+	}
+	r, err := s.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("reading %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// LoadSections returns a copy of sections whose contents are read into Content,
+// for formatters that need every content in memory
+func LoadSections(sections []Section) ([]Section, error) {
+	loaded := make([]Section, len(sections))
+	for i, section := range sections {
+		loaded[i] = section
+		if section.Open == nil {
+			continue
+		}
+		var content strings.Builder
+		if err := writeContent(&content, section); err != nil {
+			return nil, err
+		}
+		loaded[i].Content = content.String()
+		loaded[i].Open = nil
+	}
+	return loaded, nil
+}
+
+// SectionFormatter defines the interface for formatters that use section metadata
+type SectionFormatter interface {
+	// FormatSections combines sections into a single output in the given order
+	FormatSections(sections []Section) (string, error)
+}
+
+// SourceOf returns the source type of an input path.
+// This is a pure function that can be easily tested
+func SourceOf(path string) SourceType {
+	if path == StdinPath {
+		return SourceStdin
+	}
+	if u, err := url.Parse(path); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return SourceRemote
+	}
+	return SourceLocal
+}
+
+// NewSections creates sections for the files that have contents, keeping the order of files.
+// Names use DefaultPathStyle and ModTime is left as the zero time.
+// This is a pure function that can be easily tested
+func NewSections(files []string, contents map[string]string) []Section {
+	sections := make([]Section, 0, len(files))
+	for _, file := range files {
+		content, ok := contents[file]
+		if !ok {
+			continue
+		}
+		sections = append(sections, Section{
+			Path:    file,
+			Name:    DisplayPath(file, DefaultPathStyle, ""),
+			Content: content,
+			Source:  SourceOf(file),
+			Size:    int64(len(content)),
+		})
+	}
+	return sections
+}
+
+// StreamFormatter is implemented by formatters that write their output to an io.Writer
+// instead of returning it, reading contents from Section.Open as they go
+type StreamFormatter interface {
+	// FormatTo writes sections combined into a single output to w in the given order
+	FormatTo(w io.Writer, sections []Section) error
+}
+
+// FormatSections formats sections with f, passing metadata when f is a SectionFormatter.
+// Contents behind Section.Open are loaded first unless f is a StreamFormatter.
+func FormatSections(f Formatter, sections []Section) (string, error) {
+	if _, ok := f.(StreamFormatter); !ok {
+		loaded, err := LoadSections(sections)
+		if err != nil {
+			return "", err
+		}
+		sections = loaded
+	}
+	if sf, ok := f.(SectionFormatter); ok {
+		return sf.FormatSections(sections)
+	}
+
+	files := make([]string, 0, len(sections))
+	contents := make(map[string]string, len(sections))
+	for _, section := range sections {
+		files = append(files, section.Path)
+		contents[section.Path] = section.Content
+	}
+	return f.Format(files, contents)
+}
+
+// FormatTo writes sections formatted with f to w.
+// Formatters that are not StreamFormatters build the whole output in memory before it is written.
+func FormatTo(w io.Writer, f Formatter, sections []Section) error {
+	if sf, ok := f.(StreamFormatter); ok {
+		return sf.FormatTo(w, sections)
+	}
+	output, err := FormatSections(f, sections)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, output)
+	return err
+}
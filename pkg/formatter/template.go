@@ -0,0 +1,78 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// FormatTemplate is the name of the user-defined template output format
+const FormatTemplate = "template"
+
+// TemplateData is the data passed to an output template
+type TemplateData struct {
+	// Sections are the input files in output order
+	Sections []Section
+	// Count is the number of sections
+	Count int
+}
+
+// templateFuncs are the helper functions available in output templates
+var templateFuncs = template.FuncMap{
+	// indent prefixes every non-empty line of s with n spaces
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = pad + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+}
+
+// TemplateFormatter renders sections with a user-defined text/template
+type TemplateFormatter struct {
+	tmpl *template.Template
+}
+
+// NewTemplateFormatter creates a new TemplateFormatter from template text
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	tmpl, err := template.New(FormatTemplate).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing output template: %w", err)
+	}
+	return &TemplateFormatter{tmpl: tmpl}, nil
+}
+
+// Format combines multiple file contents by rendering the template
+func (f *TemplateFormatter) Format(files []string, contents map[string]string) (string, error) {
+	return f.FormatSections(NewSections(files, contents))
+}
+
+// FormatSections combines sections by rendering the template
+func (f *TemplateFormatter) FormatSections(sections []Section) (string, error) {
+	return formatStream(f, sections)
+}
+
+// FormatTo renders the template for sections to w.
+// Templates access contents as strings, so contents behind Section.Open are loaded first.
+func (f *TemplateFormatter) FormatTo(w io.Writer, sections []Section) error {
+	sections, err := LoadSections(sections)
+	if err != nil {
+		return err
+	}
+	data := TemplateData{Sections: sections, Count: len(sections)}
+	if err := f.tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("executing output template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+//go:build small
+
+package formatter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplateFormatter_FormatSections(t *testing.T) {
+	modTime := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	sections := []Section{
+		{Path: StdinPath, Content: "標準入力", Source: SourceStdin, Size: 12},
+		{Path: "docs/spec.md", Content: "# 製品仕様\n- 機能A", Source: SourceLocal, ModTime: modTime, Size: 22},
+		{Path: "https://example.com/rules.md", Content: "  # ルール  ", Source: SourceRemote, Size: 14},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "前文・セクション・フッター",
+			template: "# Context ({{.Count}} files)\n{{range .Sections}}\n## {{.BaseName}} ({{.Source}})\n{{.Content}}\n{{end}}\n-- end --",
+			want:     "# Context (3 files)\n\n## stdin (stdin)\n標準入力\n\n## spec.md (local)\n# 製品仕様\n- 機能A\n\n## rules.md (remote)\n  # ルール  \n\n-- end --",
+		},
+		{
+			name:     "ヘルパー関数",
+			template: `{{range .Sections}}{{if eq .Source "local"}}{{.Path}} {{.Size}} {{.ModTime.Format "2006-01-02"}}{{"\n"}}{{indent 2 .Content}}{{end}}{{end}}|{{range .Sections}}{{trim .Content | upper}}{{end}}`,
+			want:     "docs/spec.md 22 2025-03-15\n  # 製品仕様\n  - 機能A|標準入力# 製品仕様\n- 機能A# ルール",
+		},
+		{
+			name:     "存在しないフィールド",
+			template: "{{range .Sections}}{{.Missing}}{{end}}",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewTemplateFormatter(tt.template)
+			if err != nil {
+				t.Fatalf("NewTemplateFormatter() error = %v", err)
+			}
+			got, err := f.FormatSections(sections)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatSections() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want && !tt.wantErr {
+				t.Errorf("FormatSections() got and want differ\nGot:\n%s\n\nWant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTemplateFormatter_InvalidTemplate(t *testing.T) {
+	if _, err := NewTemplateFormatter("{{range .Sections}}"); err == nil {
+		t.Error("NewTemplateFormatter() expected error but got nil")
+	}
+}
+
+func TestTemplateFormatter_Format(t *testing.T) {
+	f, err := NewTemplateFormatter("{{range .Sections}}[{{.Path}}:{{.Source}}]{{end}}")
+	if err != nil {
+		t.Fatalf("NewTemplateFormatter() error = %v", err)
+	}
+
+	got, err := f.Format(
+		[]string{StdinPath, "spec.md", "missing.md", "https://example.com/rules.md"},
+		map[string]string{StdinPath: "a", "spec.md": "b", "https://example.com/rules.md": "c"},
+	)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	want := "[stdin:stdin][spec.md:local][https://example.com/rules.md:remote]"
+	if got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatSections(t *testing.T) {
+	sections := []Section{
+		{Path: "spec.md", Content: "A", Source: SourceLocal},
+		{Path: "rules.md", Content: "B", Source: SourceLocal},
+	}
+
+	got, err := FormatSections(NewHTMLCommentFormatter(), sections)
+	if err != nil {
+		t.Fatalf("FormatSections() error = %v", err)
+	}
+	want := "<!-- filepath: spec.md -->\nA\n\n<!-- filepath: rules.md -->\nB"
+	if got != want {
+		t.Errorf("FormatSections() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+// Package glob provides doublestar pattern matching for input file selection
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DoubleStar is the pattern segment that matches zero or more directories
+const DoubleStar = "**"
+
+// HasMeta reports whether a pattern contains any glob metacharacters
+func HasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// Match reports whether name matches the pattern.
+// Both are slash-separated; each segment is matched with path.Match,
+// and a "**" segment matches zero or more whole segments.
+// This is a pure function that can be easily tested
+func Match(pattern, name string) (bool, error) {
+	return matchSegments(splitSegments(pattern), splitSegments(name))
+}
+
+// matchSegments matches path segments against pattern segments
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == DoubleStar {
+			// Collapse consecutive "**" segments
+			rest := pattern[1:]
+			for len(rest) > 0 && rest[0] == DoubleStar {
+				rest = rest[1:]
+			}
+			if len(rest) == 0 {
+				return true, nil
+			}
+			for i := 0; i <= len(name); i++ {
+				matched, err := matchSegments(rest, name[i:])
+				if err != nil || matched {
+					return matched, err
+				}
+			}
+			return false, nil
+		}
+
+		if len(name) == 0 {
+			return false, nil
+		}
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil || !matched {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+// splitSegments splits a slash-separated path into its non-empty segments,
+// keeping a leading empty segment for absolute paths
+func splitSegments(p string) []string {
+	segments := strings.Split(p, "/")
+	result := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		if segment == "" && i != 0 {
+			continue
+		}
+		if segment == "." {
+			continue
+		}
+		result = append(result, segment)
+	}
+	return result
+}
+
+// Base returns the longest leading directory of a pattern that contains no metacharacters
+func Base(pattern string) string {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	base := make([]string, 0, len(segments))
+	for _, segment := range segments[:len(segments)-1] {
+		if HasMeta(segment) {
+			break
+		}
+		base = append(base, segment)
+	}
+	if len(base) == 0 {
+		return "."
+	}
+	if len(base) == 1 && base[0] == "" {
+		return string(filepath.Separator)
+	}
+	return filepath.FromSlash(strings.Join(base, "/"))
+}
+
+// Expand returns the regular files matching a pattern in lexical order,
+// along with the directories that were searched and may contain future matches.
+// Files and directories excluded by ignore are skipped; ignore may be nil.
+func Expand(pattern string, ignore *Ignore) (files []string, dirs []string, err error) {
+	pattern = filepath.Clean(pattern)
+	slashPattern := filepath.ToSlash(pattern)
+	maxDepth := -1
+	if !strings.Contains(slashPattern, DoubleStar) {
+		maxDepth = len(splitSegments(slashPattern)) - 1
+	}
+
+	base := Base(pattern)
+	if _, err := os.Stat(base); os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+
+	err = filepath.WalkDir(base, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if p == base {
+				return walkErr
+			}
+			// Skip unreadable entries below the base directory
+			return nil
+		}
+
+		if ignore.Ignored(p, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if maxDepth >= 0 && len(splitSegments(filepath.ToSlash(p))) > maxDepth {
+				return fs.SkipDir
+			}
+			dirs = append(dirs, p)
+			return nil
+		}
+
+		matched, err := Match(slashPattern, filepath.ToSlash(p))
+		if err != nil {
+			return err
+		}
+		if matched && isRegular(p, d) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Strings(files)
+	sort.Strings(dirs)
+	return files, dirs, nil
+}
+
+// isRegular reports whether a walked entry is a regular file or a symlink to one.
+// Symlinks to directories are not followed.
+func isRegular(p string, d fs.DirEntry) bool {
+	if d.Type().IsRegular() {
+		return true
+	}
+	if d.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(p)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,214 @@
+//go:build small
+
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHasMeta(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{pattern: "docs/rules.md", want: false},
+		{pattern: "docs/*.md", want: true},
+		{pattern: "docs/**/*.md", want: true},
+		{pattern: "docs/rule?.md", want: true},
+		{pattern: "docs/[ab].md", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := HasMeta(tt.pattern); got != tt.want {
+				t.Errorf("HasMeta(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "single star", pattern: "docs/*.md", path: "docs/a.md", want: true},
+		{name: "single star does not cross directories", pattern: "docs/*.md", path: "docs/x/a.md", want: false},
+		{name: "double star matches zero directories", pattern: "docs/**/*.md", path: "docs/a.md", want: true},
+		{name: "double star matches nested directories", pattern: "docs/**/*.md", path: "docs/x/y/a.md", want: true},
+		{name: "double star at end", pattern: "docs/**", path: "docs/x/a.txt", want: true},
+		{name: "double star at start", pattern: "**/rules.md", path: "a/b/rules.md", want: true},
+		{name: "extension mismatch", pattern: "docs/**/*.md", path: "docs/x/a.txt", want: false},
+		{name: "different base", pattern: "docs/**/*.md", path: "src/a.md", want: false},
+		{name: "absolute path", pattern: "/tmp/**/*.md", path: "/tmp/x/a.md", want: true},
+		{name: "relative pattern against absolute path", pattern: "tmp/*.md", path: "/tmp/a.md", want: false},
+		{name: "question mark", pattern: "rule?.md", path: "rule1.md", want: true},
+		{name: "character class", pattern: "[ab].md", path: "c.md", want: false},
+		{name: "malformed pattern", pattern: "[.md", path: "a.md", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(tt.pattern, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Match(%q, %q) error = %v, wantErr %v", tt.pattern, tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBase(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "*.md", want: "."},
+		{pattern: "docs/*.md", want: "docs"},
+		{pattern: "docs/rules/**/*.md", want: filepath.Join("docs", "rules")},
+		{pattern: "docs/*/rules.md", want: "docs"},
+		{pattern: "/*.md", want: string(filepath.Separator)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := Base(tt.pattern); got != tt.want {
+				t.Errorf("Base(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"docs/b.md",
+		"docs/a.md",
+		"docs/notes.txt",
+		"docs/rules/z.md",
+		"docs/rules/deep/c.md",
+		"src/main.md",
+	} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		pattern   string
+		exclude   []string
+		wantFiles []string
+		wantDirs  []string
+	}{
+		{
+			name:      "single level",
+			pattern:   "docs/*.md",
+			wantFiles: []string{"docs/a.md", "docs/b.md"},
+			wantDirs:  []string{"docs"},
+		},
+		{
+			name:      "recursive in lexical order",
+			pattern:   "docs/**/*.md",
+			wantFiles: []string{"docs/a.md", "docs/b.md", "docs/rules/deep/c.md", "docs/rules/z.md"},
+			wantDirs:  []string{"docs", "docs/rules", "docs/rules/deep"},
+		},
+		{
+			name:      "all files in directory",
+			pattern:   "docs/rules/**",
+			wantFiles: []string{"docs/rules/deep/c.md", "docs/rules/z.md"},
+			wantDirs:  []string{"docs/rules", "docs/rules/deep"},
+		},
+		{
+			name:      "excluded files and directories",
+			pattern:   "docs/**/*.md",
+			exclude:   []string{"b.md", "deep/"},
+			wantFiles: []string{"docs/a.md", "docs/rules/z.md"},
+			wantDirs:  []string{"docs", "docs/rules"},
+		},
+		{
+			name:      "missing base directory",
+			pattern:   "missing/**/*.md",
+			wantFiles: nil,
+			wantDirs:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ignore, err := NewIgnore(dir, tt.exclude)
+			if err != nil {
+				t.Fatalf("NewIgnore() error = %v", err)
+			}
+			files, dirs, err := Expand(filepath.Join(dir, tt.pattern), ignore)
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			if !equalPaths(dir, files, tt.wantFiles) {
+				t.Errorf("Expand() files = %v, want %v", files, tt.wantFiles)
+			}
+			if !equalPaths(dir, dirs, tt.wantDirs) {
+				t.Errorf("Expand() dirs = %v, want %v", dirs, tt.wantDirs)
+			}
+		})
+	}
+}
+
+// TestExpand_Symlinks tests that symlinks to regular files match like the files themselves
+func TestExpand_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "shared"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shared", "rules.md"), []byte("rules"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{
+		"docs/rules.md":  "../shared/rules.md",
+		"docs/broken.md": "../shared/missing.md",
+		"docs/shared.md": "../shared",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(link))); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+
+	files, dirs, err := Expand(filepath.Join(dir, "docs/*.md"), nil)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	// Broken symlinks and symlinks to directories are dropped
+	if want := []string{"docs/rules.md"}; !equalPaths(dir, files, want) {
+		t.Errorf("Expand() files = %v, want %v", files, want)
+	}
+	if want := []string{"docs"}; !equalPaths(dir, dirs, want) {
+		t.Errorf("Expand() dirs = %v, want %v", dirs, want)
+	}
+}
+
+// equalPaths compares paths with slash-separated paths relative to dir
+func equalPaths(dir string, got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != filepath.Join(dir, filepath.FromSlash(want[i])) {
+			return false
+		}
+	}
+	return true
+}
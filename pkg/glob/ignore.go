@@ -0,0 +1,148 @@
+package glob
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the name of the ignore file at the project root
+const IgnoreFileName = ".wampaignore"
+
+// ignoreRule is a single parsed line of gitignore syntax
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// Ignore matches paths against gitignore-style patterns relative to a root directory
+type Ignore struct {
+	root  string
+	rules []ignoreRule
+}
+
+// NewIgnore creates an Ignore from gitignore-style patterns.
+// Patterns are resolved relative to root, and later patterns take precedence.
+func NewIgnore(root string, patterns []string) (*Ignore, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]ignoreRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rule, ok := parseIgnoreRule(pattern)
+		if !ok {
+			continue
+		}
+		if err := ValidatePattern(rule.pattern); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return &Ignore{root: absRoot, rules: rules}, nil
+}
+
+// ParseIgnoreFile splits the content of an ignore file into patterns
+func ParseIgnoreFile(data []byte) []string {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	patterns := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if _, ok := parseIgnoreRule(line); ok {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns
+}
+
+// ValidatePattern reports whether a pattern is well formed
+func ValidatePattern(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseIgnoreRule parses a line of gitignore syntax.
+// It returns false for blank lines and comments.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	// Trailing spaces are ignored unless escaped
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// A pattern with a slash is anchored to the root; otherwise it matches at any depth
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = DoubleStar + "/" + line
+	}
+	rule.pattern = line
+
+	return rule, true
+}
+
+// Ignored reports whether a path is excluded.
+// A path is also excluded when any of its parent directories below the root is excluded.
+// Paths outside the root are never excluded.
+func (ig *Ignore) Ignored(p string, isDir bool) bool {
+	if ig == nil || len(ig.rules) == 0 {
+		return false
+	}
+
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(ig.root, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i <= len(segments); i++ {
+		prefixIsDir := i < len(segments) || isDir
+		if ig.matchRules(strings.Join(segments[:i], "/"), prefixIsDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRules applies the rules to a single relative path; the last matching rule wins
+func (ig *Ignore) matchRules(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matched, err := Match(rule.pattern, rel); err == nil && matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
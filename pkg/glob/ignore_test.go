@@ -0,0 +1,78 @@
+//go:build small
+
+package glob
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIgnoreFile(t *testing.T) {
+	data := []byte("# drafts\n*.draft.md\n\n  \nvendor/\r\n!keep.draft.md\n\\#literal.md\n")
+	want := []string{"*.draft.md", "vendor/", "!keep.draft.md", "\\#literal.md"}
+
+	got := ParseIgnoreFile(data)
+	if len(got) != len(want) {
+		t.Fatalf("ParseIgnoreFile() = %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("ParseIgnoreFile()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestIgnore_Ignored(t *testing.T) {
+	root := t.TempDir()
+
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{name: "no patterns", patterns: nil, path: "docs/a.md", want: false},
+		{name: "basename at any depth", patterns: []string{"*.draft.md"}, path: "docs/x/a.draft.md", want: true},
+		{name: "basename does not match", patterns: []string{"*.draft.md"}, path: "docs/a.md", want: false},
+		{name: "anchored pattern", patterns: []string{"/gen/*.md"}, path: "gen/api.md", want: true},
+		{name: "anchored pattern at other depth", patterns: []string{"/gen/*.md"}, path: "docs/gen/api.md", want: false},
+		{name: "pattern with slash is anchored", patterns: []string{"docs/gen"}, path: "docs/gen/api.md", want: true},
+		{name: "directory only pattern excludes contents", patterns: []string{"vendor/"}, path: "docs/vendor/lib.md", want: true},
+		{name: "directory only pattern skips files", patterns: []string{"vendor/"}, path: "docs/vendor", isDir: false, want: false},
+		{name: "directory only pattern matches directory", patterns: []string{"vendor/"}, path: "vendor", isDir: true, want: true},
+		{name: "negation", patterns: []string{"*.draft.md", "!keep.draft.md"}, path: "docs/keep.draft.md", want: false},
+		{name: "last match wins", patterns: []string{"!keep.draft.md", "*.draft.md"}, path: "docs/keep.draft.md", want: true},
+		{name: "negation cannot re-include from excluded directory", patterns: []string{"vendor/", "!vendor/keep.md"}, path: "vendor/keep.md", want: true},
+		{name: "double star", patterns: []string{"docs/**/internal"}, path: "docs/a/b/internal/x.md", want: true},
+		{name: "escaped hash", patterns: []string{"\\#notes.md"}, path: "#notes.md", want: true},
+		{name: "comment is ignored", patterns: []string{"# a.md"}, path: "a.md", want: false},
+		{name: "outside root", patterns: []string{"*.md"}, path: "../outside.md", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ig, err := NewIgnore(root, tt.patterns)
+			if err != nil {
+				t.Fatalf("NewIgnore() error = %v", err)
+			}
+			path := filepath.Join(root, filepath.FromSlash(tt.path))
+			if got := ig.Ignored(path, tt.isDir); got != tt.want {
+				t.Errorf("Ignored(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewIgnore_InvalidPattern(t *testing.T) {
+	if _, err := NewIgnore(t.TempDir(), []string{"[.md"}); err == nil {
+		t.Error("NewIgnore() expected error but got nil")
+	}
+}
+
+func TestIgnore_NilIgnoresNothing(t *testing.T) {
+	var ig *Ignore
+	if ig.Ignored("docs/a.md", false) {
+		t.Error("nil Ignore excluded a path")
+	}
+}
@@ -0,0 +1,250 @@
+// Package httpclient provides the HTTP client used to fetch remote input files
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultConnectTimeout limits establishing a connection, including the TLS handshake
+	DefaultConnectTimeout = 10 * time.Second
+	// DefaultReadTimeout limits waiting for the response headers and for each read of the body
+	DefaultReadTimeout = 30 * time.Second
+	// DefaultRetries is the number of retries after the first attempt
+	DefaultRetries = 3
+	// DefaultBackoff is the delay before the first retry
+	DefaultBackoff = 500 * time.Millisecond
+	// DefaultMaxBackoff is the maximum delay between attempts
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// Options configures the HTTP client
+type Options struct {
+	// ConnectTimeout limits establishing a connection, including the TLS handshake
+	ConnectTimeout time.Duration
+	// ReadTimeout limits waiting for the response headers and for each read of the body
+	ReadTimeout time.Duration
+	// Retries is the number of retries after the first attempt; zero disables retrying
+	Retries int
+	// Backoff is the delay before the first retry; it doubles for every further retry
+	Backoff time.Duration
+	// MaxBackoff caps the delay between attempts, including delays requested by Retry-After
+	MaxBackoff time.Duration
+	// TLS configures certificate verification and client certificates
+	TLS TLSOptions
+	// Proxy is the URL of the proxy used for every request, or ProxyDirect to disable proxies.
+	// When empty, the proxy is taken from HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+	Proxy string
+	// CheckRedirect is called before following a redirect, like http.Client.CheckRedirect.
+	// When nil, up to 10 redirects are followed.
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+}
+
+// ProxyDirect disables proxies, including those set in the environment
+const ProxyDirect = "direct"
+
+// TLSOptions configures TLS connections
+type TLSOptions struct {
+	// CAFile is a PEM bundle of certificate authorities trusted in addition to the system roots
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and its private key
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables server certificate verification
+	InsecureSkipVerify bool
+}
+
+// DefaultOptions returns the options used when nothing is configured
+func DefaultOptions() Options {
+	return Options{
+		ConnectTimeout: DefaultConnectTimeout,
+		ReadTimeout:    DefaultReadTimeout,
+		Retries:        DefaultRetries,
+		Backoff:        DefaultBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+	}
+}
+
+// Doer defines the port for executing HTTP requests
+type Doer interface {
+	// Do sends an HTTP request and returns an HTTP response
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// New creates a client that applies the timeouts, TLS and proxy settings of opts and retries failed requests
+func New(opts Options) (Doer, error) {
+	transport, err := NewTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TLS.InsecureSkipVerify {
+		log.Printf("WARNING: TLS certificate verification is DISABLED for remote files (insecure_skip_verify). " +
+			"Anyone on the network path can read and modify the fetched content.")
+	}
+	return NewRetrier(NewTimeoutClient(&http.Client{Transport: transport, CheckRedirect: opts.CheckRedirect}, opts.ReadTimeout), opts), nil
+}
+
+// NewTransport creates a transport with the timeouts, TLS and proxy settings of opts
+func NewTransport(opts Options) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   opts.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	transport.ResponseHeaderTimeout = opts.ReadTimeout
+
+	tlsConfig, err := NewTLSConfig(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	proxy, err := ProxyFunc(opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+	return transport, nil
+}
+
+// NewTLSConfig creates the TLS configuration for opts
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be specified together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// ProxyFunc returns the proxy selection for a proxy setting.
+// An empty setting uses the environment and ProxyDirect disables proxies.
+func ProxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyDirect:
+		return nil, nil
+	}
+	u, err := ParseProxy(proxy)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyURL(u), nil
+}
+
+// ParseProxy parses an explicit proxy URL.
+// This is a pure function that can be easily tested
+func ParseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https, socks5 or socks5h", proxy)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+	return u, nil
+}
+
+// timeoutClient aborts a request when its response body stalls
+type timeoutClient struct {
+	base        Doer
+	readTimeout time.Duration
+}
+
+// NewTimeoutClient wraps base so that a response body that delivers no data for readTimeout is aborted.
+// A zero readTimeout disables the limit.
+func NewTimeoutClient(base Doer, readTimeout time.Duration) Doer {
+	if readTimeout <= 0 {
+		return base
+	}
+	return &timeoutClient{base: base, readTimeout: readTimeout}
+}
+
+// Do sends the request and arms the read timeout on its response body
+func (c *timeoutClient) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	resp, err := c.base.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	body := &timeoutBody{body: resp.Body, cancel: cancel, timeout: c.readTimeout}
+	body.timer = time.AfterFunc(c.readTimeout, func() {
+		body.expired.Store(true)
+		cancel()
+	})
+	resp.Body = body
+	return resp, nil
+}
+
+// timeoutBody cancels its request when no data is read within the timeout
+type timeoutBody struct {
+	body    io.ReadCloser
+	cancel  context.CancelFunc
+	timeout time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// Read reads from the body and restarts the timeout
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.expired.Load() {
+		return n, fmt.Errorf("read timeout: no data received for %s", b.timeout)
+	}
+	b.timer.Reset(b.timeout)
+	return n, err
+}
+
+// Close stops the timeout and closes the body
+func (b *timeoutBody) Close() error {
+	b.timer.Stop()
+	err := b.body.Close()
+	b.cancel()
+	return err
+}
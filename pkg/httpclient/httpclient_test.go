@@ -0,0 +1,227 @@
+//go:build small
+
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pipeDoer responds with a body that is written by the test
+type pipeDoer struct {
+	body *io.PipeReader
+	req  *http.Request
+}
+
+func (p *pipeDoer) Do(req *http.Request) (*http.Response, error) {
+	p.req = req
+	go func() {
+		<-req.Context().Done()
+		p.body.CloseWithError(req.Context().Err())
+	}()
+	return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: p.body}, nil
+}
+
+func TestTimeoutClient_Do(t *testing.T) {
+	tests := []struct {
+		name    string
+		writes  []string
+		pause   time.Duration
+		wantErr string
+	}{
+		{
+			name:   "途切れずに届く本文は読める",
+			writes: []string{"a", "b", "c"},
+			pause:  10 * time.Millisecond,
+		},
+		{
+			name:    "本文が止まるとタイムアウトする",
+			writes:  []string{"a", "b"},
+			pause:   300 * time.Millisecond,
+			wantErr: "read timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w := io.Pipe()
+			c := NewTimeoutClient(&pipeDoer{body: r}, 100*time.Millisecond)
+
+			go func() {
+				for _, s := range tt.writes {
+					time.Sleep(tt.pause)
+					if _, err := w.Write([]byte(s)); err != nil {
+						return
+					}
+				}
+				w.Close()
+			}()
+
+			req, _ := http.NewRequest(http.MethodGet, "https://example.com/a.md", nil)
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			data, err := io.ReadAll(resp.Body)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadAll() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if got := string(data); got != strings.Join(tt.writes, "") {
+				t.Errorf("body = %q, want %q", got, strings.Join(tt.writes, ""))
+			}
+		})
+	}
+}
+
+func TestNewTimeoutClient_Disabled(t *testing.T) {
+	base := &pipeDoer{}
+	if got := NewTimeoutClient(base, 0); got != Doer(base) {
+		t.Errorf("NewTimeoutClient() with zero timeout should return the base client")
+	}
+}
+
+// writeTestCert writes a self-signed certificate and its key as PEM files
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "wampa test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	notPEM := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		opts      TLSOptions
+		wantErr   bool
+		wantRoots bool
+		wantCerts int
+	}{
+		{name: "既定", opts: TLSOptions{}},
+		{name: "CAバンドル", opts: TLSOptions{CAFile: certFile}, wantRoots: true},
+		{name: "クライアント証明書", opts: TLSOptions{CertFile: certFile, KeyFile: keyFile}, wantCerts: 1},
+		{name: "証明書検証を無効化", opts: TLSOptions{InsecureSkipVerify: true}},
+		{name: "存在しないCAバンドル", opts: TLSOptions{CAFile: filepath.Join(dir, "missing.pem")}, wantErr: true},
+		{name: "証明書を含まないCAバンドル", opts: TLSOptions{CAFile: notPEM}, wantErr: true},
+		{name: "鍵のないクライアント証明書", opts: TLSOptions{CertFile: certFile}, wantErr: true},
+		{name: "対にならない鍵", opts: TLSOptions{CertFile: certFile, KeyFile: notPEM}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTLSConfig(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if (got.RootCAs != nil) != tt.wantRoots {
+				t.Errorf("NewTLSConfig() RootCAs set = %v, want %v", got.RootCAs != nil, tt.wantRoots)
+			}
+			if len(got.Certificates) != tt.wantCerts {
+				t.Errorf("NewTLSConfig() certificates = %d, want %d", len(got.Certificates), tt.wantCerts)
+			}
+			if got.InsecureSkipVerify != tt.opts.InsecureSkipVerify {
+				t.Errorf("NewTLSConfig() InsecureSkipVerify = %v, want %v", got.InsecureSkipVerify, tt.opts.InsecureSkipVerify)
+			}
+		})
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://gitea.internal/rules.md", nil)
+
+	tests := []struct {
+		name    string
+		proxy   string
+		want    string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "明示的なプロキシ", proxy: "http://proxy.internal:3128", want: "http://proxy.internal:3128"},
+		{name: "SOCKSプロキシ", proxy: "socks5://127.0.0.1:1080", want: "socks5://127.0.0.1:1080"},
+		{name: "プロキシを使わない", proxy: ProxyDirect, wantNil: true},
+		{name: "未対応のスキーム", proxy: "ftp://proxy.internal", wantErr: true},
+		{name: "ホストがない", proxy: "http://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, err := ProxyFunc(tt.proxy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProxyFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.wantNil {
+				if proxy != nil {
+					t.Errorf("ProxyFunc() = non-nil, want nil")
+				}
+				return
+			}
+			u, err := proxy(req)
+			if err != nil {
+				t.Fatalf("proxy() error = %v", err)
+			}
+			if u.String() != tt.want {
+				t.Errorf("proxy() = %s, want %s", u, tt.want)
+			}
+		})
+	}
+}
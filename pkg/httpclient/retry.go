@@ -0,0 +1,175 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxDrain is the amount of a discarded response body read so that its connection can be reused
+const maxDrain = 64 << 10
+
+// Retrier retries requests that fail with a network error, 429 Too Many Requests or a 5xx status
+type Retrier struct {
+	base       Doer
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	// jitter returns a random number in [0, 1)
+	jitter func() float64
+	// sleep waits for d or until ctx is done
+	sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+	logf  func(format string, args ...any)
+}
+
+// NewRetrier creates a Retrier that sends requests with base
+func NewRetrier(base Doer, opts Options) *Retrier {
+	return &Retrier{
+		base:       base,
+		retries:    opts.Retries,
+		backoff:    opts.Backoff,
+		maxBackoff: opts.MaxBackoff,
+		jitter:     rand.Float64,
+		sleep:      sleep,
+		now:        time.Now,
+		logf:       log.Printf,
+	}
+}
+
+// Do sends the request, retrying it with jittered exponential backoff.
+// The response of the last attempt is returned when every attempt is retryable.
+func (r *Retrier) Do(req *http.Request) (*http.Response, error) {
+	attempts := r.retries + 1
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			if err := rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := r.base.Do(req)
+		cause, retryable := retryCause(resp, err)
+		if req.Context().Err() != nil {
+			retryable = false
+		}
+		if !retryable || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		if attempt >= attempts {
+			r.logf("Attempt %d/%d for %s failed: %s; giving up", attempt, attempts, req.URL.Redacted(), cause)
+			return resp, err
+		}
+
+		delay := r.delay(attempt, resp)
+		r.logf("Attempt %d/%d for %s failed: %s; retrying in %s", attempt, attempts, req.URL.Redacted(), cause, delay.Round(time.Millisecond))
+		if resp != nil {
+			discard(resp)
+		}
+		if err := r.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// delay returns the wait before the retry that follows the given attempt.
+// A Retry-After header takes precedence over the backoff; both are capped by the maximum backoff.
+func (r *Retrier) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), r.now()); ok {
+			return min(d, r.maxBackoff)
+		}
+	}
+	return Backoff(attempt, r.backoff, r.maxBackoff, r.jitter())
+}
+
+// Backoff returns the jittered delay before retry number attempt (starting at 1).
+// The delay doubles with every attempt up to maxDelay, and jitter in [0, 1) picks a point in its upper half.
+// This is a pure function that can be easily tested
+func Backoff(attempt int, base, maxDelay time.Duration, jitter float64) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+	return d/2 + time.Duration(jitter*float64(d/2))
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+// It reports false when the header is missing or invalid.
+// This is a pure function that can be easily tested
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// retryCause describes why an attempt failed and reports whether it can be retried
+func retryCause(resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		// Cancellation and certificate problems do not go away by retrying
+		var certErr *tls.CertificateVerificationError
+		if errors.Is(err, context.Canceled) || errors.As(err, &certErr) {
+			return err.Error(), false
+		}
+		return err.Error(), true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return resp.Status, true
+	}
+	return "", false
+}
+
+// rewind restores the body of a request that is sent again
+func rewind(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Errorf("rewinding request body: %w", err)
+	}
+	req.Body = body
+	return nil
+}
+
+// discard drains and closes a response that is not returned
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	resp.Body.Close()
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,272 @@
+//go:build small
+
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDoer returns the queued results in order
+type fakeDoer struct {
+	results []fakeResult
+	calls   int
+}
+
+type fakeResult struct {
+	status     int
+	retryAfter string
+	err        error
+}
+
+func (f *fakeDoer) Do(req *http.Request) (*http.Response, error) {
+	r := f.results[f.calls]
+	f.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	resp := &http.Response{
+		StatusCode: r.status,
+		Status:     fmt.Sprintf("%d %s", r.status, http.StatusText(r.status)),
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("body")),
+	}
+	if r.retryAfter != "" {
+		resp.Header.Set("Retry-After", r.retryAfter)
+	}
+	return resp, nil
+}
+
+func newTestRetrier(base Doer, retries int) (*Retrier, *[]time.Duration, *[]string) {
+	var delays []time.Duration
+	var logs []string
+	r := NewRetrier(base, Options{Retries: retries, Backoff: time.Second, MaxBackoff: 10 * time.Second})
+	r.jitter = func() float64 { return 0 }
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	r.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+	r.logf = func(format string, args ...any) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+	return r, &delays, &logs
+}
+
+func TestRetrier_Do(t *testing.T) {
+	netErr := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		retries    int
+		results    []fakeResult
+		wantStatus int
+		wantErr    bool
+		wantCalls  int
+		wantDelays []time.Duration
+		wantLogs   []string
+	}{
+		{
+			name:       "成功時はリトライしない",
+			retries:    3,
+			results:    []fakeResult{{status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantCalls:  1,
+		},
+		{
+			name:       "404はリトライしない",
+			retries:    3,
+			results:    []fakeResult{{status: http.StatusNotFound}},
+			wantStatus: http.StatusNotFound,
+			wantCalls:  1,
+		},
+		{
+			name:       "5xxの後に成功",
+			retries:    3,
+			results:    []fakeResult{{status: http.StatusBadGateway}, {status: http.StatusServiceUnavailable}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantCalls:  3,
+			wantDelays: []time.Duration{500 * time.Millisecond, time.Second},
+			wantLogs: []string{
+				"Attempt 1/4 for https://example.com/a.md failed: 502 Bad Gateway; retrying in 500ms",
+				"Attempt 2/4 for https://example.com/a.md failed: 503 Service Unavailable; retrying in 1s",
+			},
+		},
+		{
+			name:       "ネットワークエラーの後に成功",
+			retries:    1,
+			results:    []fakeResult{{err: netErr}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+			wantDelays: []time.Duration{500 * time.Millisecond},
+			wantLogs: []string{
+				"Attempt 1/2 for https://example.com/a.md failed: connection refused; retrying in 500ms",
+			},
+		},
+		{
+			name:       "429はRetry-Afterに従う",
+			retries:    1,
+			results:    []fakeResult{{status: http.StatusTooManyRequests, retryAfter: "7"}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+			wantDelays: []time.Duration{7 * time.Second},
+			wantLogs: []string{
+				"Attempt 1/2 for https://example.com/a.md failed: 429 Too Many Requests; retrying in 7s",
+			},
+		},
+		{
+			name:       "Retry-Afterは最大待ち時間で打ち切る",
+			retries:    1,
+			results:    []fakeResult{{status: http.StatusServiceUnavailable, retryAfter: "3600"}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+			wantDelays: []time.Duration{10 * time.Second},
+			wantLogs: []string{
+				"Attempt 1/2 for https://example.com/a.md failed: 503 Service Unavailable; retrying in 10s",
+			},
+		},
+		{
+			name:       "リトライ回数を使い切ると最後の応答を返す",
+			retries:    1,
+			results:    []fakeResult{{status: http.StatusInternalServerError}, {status: http.StatusBadGateway}},
+			wantStatus: http.StatusBadGateway,
+			wantCalls:  2,
+			wantDelays: []time.Duration{500 * time.Millisecond},
+			wantLogs: []string{
+				"Attempt 1/2 for https://example.com/a.md failed: 500 Internal Server Error; retrying in 500ms",
+				"Attempt 2/2 for https://example.com/a.md failed: 502 Bad Gateway; giving up",
+			},
+		},
+		{
+			name:      "リトライ回数を使い切るとエラーを返す",
+			retries:   0,
+			results:   []fakeResult{{err: netErr}},
+			wantErr:   true,
+			wantCalls: 1,
+			wantLogs: []string{
+				"Attempt 1/1 for https://example.com/a.md failed: connection refused; giving up",
+			},
+		},
+		{
+			name:      "証明書エラーはリトライしない",
+			retries:   3,
+			results:   []fakeResult{{err: &tls.CertificateVerificationError{Err: errors.New("unknown authority")}}},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "キャンセルはリトライしない",
+			retries:   3,
+			results:   []fakeResult{{err: fmt.Errorf("fetching: %w", context.Canceled)}},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &fakeDoer{results: tt.results}
+			r, delays, logs := newTestRetrier(base, tt.retries)
+
+			req, _ := http.NewRequest(http.MethodGet, "https://example.com/a.md", nil)
+			resp, err := r.Do(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				defer resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("Do() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+			if base.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", base.calls, tt.wantCalls)
+			}
+			if fmt.Sprint(*delays) != fmt.Sprint(tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", *delays, tt.wantDelays)
+			}
+			if strings.Join(*logs, "\n") != strings.Join(tt.wantLogs, "\n") {
+				t.Errorf("logs = %q, want %q", *logs, tt.wantLogs)
+			}
+		})
+	}
+}
+
+func TestRetrier_DoCancelledDuringBackoff(t *testing.T) {
+	base := &fakeDoer{results: []fakeResult{{status: http.StatusServiceUnavailable}, {status: http.StatusOK}}}
+	r, _, _ := newTestRetrier(base, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.sleep = sleep
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com/a.md", nil)
+
+	// The cancelled context stops retrying before the first retry
+	resp, err := r.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if base.calls != 1 {
+		t.Errorf("calls = %d, want 1", base.calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		jitter  float64
+		want    time.Duration
+	}{
+		{name: "1回目・ジッターなし", attempt: 1, jitter: 0, want: 500 * time.Millisecond},
+		{name: "1回目・最大ジッター", attempt: 1, jitter: 0.999, want: 999500 * time.Microsecond},
+		{name: "3回目は4倍", attempt: 3, jitter: 0, want: 2 * time.Second},
+		{name: "上限で打ち切る", attempt: 10, jitter: 0, want: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Backoff(tt.attempt, time.Second, 10*time.Second, tt.jitter)
+			if got != tt.want {
+				t.Errorf("Backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "秒数", value: "120", want: 2 * time.Minute, wantOK: true},
+		{name: "HTTP日付", value: "Wed, 01 Jan 2025 00:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{name: "過去の日付", value: "Tue, 31 Dec 2024 23:59:00 GMT", want: 0, wantOK: true},
+		{name: "空", value: "", wantOK: false},
+		{name: "負の秒数", value: "-1", wantOK: false},
+		{name: "不正な値", value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRetryAfter(tt.value, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseRetryAfter() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
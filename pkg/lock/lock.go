@@ -0,0 +1,190 @@
+// Package lock provides the lockfile that pins remote input files by content hash
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	outputfile "github.com/toms74209200/wampa/pkg/output"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+// FileName is the name of the lockfile next to the configuration file
+const FileName = "wampa.lock"
+
+// Version is the current format version of the lockfile
+const Version = 1
+
+// ErrMismatch is returned when remote content does not match its locked hash
+var ErrMismatch = errors.New("content does not match lockfile")
+
+// Entry records the resolved state of a single remote file
+type Entry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	SHA256       string    `json:"sha256"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// NewEntry creates an entry from the state and content of a fetched remote file.
+// This is a pure function that can be easily tested
+func NewEntry(state watcher.RemoteFileState, content []byte, fetchedAt time.Time) Entry {
+	return Entry{
+		URL:          state.URL,
+		ETag:         state.ETag,
+		LastModified: state.LastModified,
+		SHA256:       Hash(content),
+		FetchedAt:    fetchedAt.UTC(),
+	}
+}
+
+// State returns the remote file state recorded by the entry
+func (e Entry) State() watcher.RemoteFileState {
+	return watcher.RemoteFileState{
+		URL:          e.URL,
+		ETag:         e.ETag,
+		LastModified: e.LastModified,
+	}
+}
+
+// Hash returns the hex-encoded SHA-256 of content
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Lock holds the pinned remote files of a project
+type Lock struct {
+	entries map[string]Entry
+	changed bool
+}
+
+// lockFile is the JSON representation of a Lock
+type lockFile struct {
+	Version int     `json:"version"`
+	Remotes []Entry `json:"remotes"`
+}
+
+// New creates an empty Lock
+func New() *Lock {
+	return &Lock{entries: make(map[string]Entry)}
+}
+
+// Parse parses lockfile data
+func Parse(data []byte) (*Lock, error) {
+	var file lockFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid lockfile: %w", err)
+	}
+	if file.Version != Version {
+		return nil, fmt.Errorf("unsupported lockfile version %d", file.Version)
+	}
+
+	l := New()
+	for i, entry := range file.Remotes {
+		if entry.URL == "" {
+			return nil, fmt.Errorf("remotes[%d].url is empty", i)
+		}
+		if entry.SHA256 == "" {
+			return nil, fmt.Errorf("remotes[%d].sha256 is empty", i)
+		}
+		l.entries[entry.URL] = entry
+	}
+	return l, nil
+}
+
+// Load reads the lockfile at path. A missing lockfile yields an empty Lock.
+func Load(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return New(), nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return Parse(data)
+}
+
+// Marshal returns the lockfile data with entries sorted by URL
+func (l *Lock) Marshal() ([]byte, error) {
+	file := lockFile{Version: Version, Remotes: make([]Entry, 0, len(l.entries))}
+	for _, entry := range l.entries {
+		file.Remotes = append(file.Remotes, entry)
+	}
+	sort.Slice(file.Remotes, func(i, j int) bool {
+		return file.Remotes[i].URL < file.Remotes[j].URL
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding lockfile: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Save writes the lockfile to path when it has changed since it was loaded
+func (l *Lock) Save(path string) error {
+	if !l.changed {
+		return nil
+	}
+	data, err := l.Marshal()
+	if err != nil {
+		return err
+	}
+	if _, err := outputfile.Write(path, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	l.changed = false
+	return nil
+}
+
+// Get returns the entry of a URL
+func (l *Lock) Get(url string) (Entry, bool) {
+	entry, ok := l.entries[url]
+	return entry, ok
+}
+
+// Set records the entry of a URL
+func (l *Lock) Set(entry Entry) {
+	l.entries[entry.URL] = entry
+	l.changed = true
+}
+
+// Verify checks content against the locked hash of a URL.
+// It reports false when the URL is not locked.
+func (l *Lock) Verify(url string, content []byte) (bool, error) {
+	entry, ok := l.entries[url]
+	if !ok {
+		return false, nil
+	}
+	if hash := Hash(content); hash != entry.SHA256 {
+		return true, fmt.Errorf("%w: %s has sha256 %s, locked %s", ErrMismatch, url, hash, entry.SHA256)
+	}
+	return true, nil
+}
+
+// Prune removes the entries of URLs that are not in urls
+func (l *Lock) Prune(urls []string) {
+	keep := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		keep[url] = true
+	}
+	for url := range l.entries {
+		if !keep[url] {
+			delete(l.entries, url)
+			l.changed = true
+		}
+	}
+}
+
+// Changed reports whether the lock differs from the saved lockfile
+func (l *Lock) Changed() bool {
+	return l.changed
+}
@@ -0,0 +1,116 @@
+//go:build small
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+const testURL = "https://example.com/rules.md"
+
+func TestLock_Verify(t *testing.T) {
+	l := New()
+	l.Set(NewEntry(watcher.RemoteFileState{URL: testURL, ETag: `"v1"`}, []byte("# Rules"), time.Now()))
+
+	tests := []struct {
+		name       string
+		url        string
+		content    string
+		wantLocked bool
+		wantErr    error
+	}{
+		{name: "matching content", url: testURL, content: "# Rules", wantLocked: true},
+		{name: "changed content", url: testURL, content: "# Rules v2", wantLocked: true, wantErr: ErrMismatch},
+		{name: "unlocked URL", url: "https://example.com/other.md", content: "x", wantLocked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locked, err := l.Verify(tt.url, []byte(tt.content))
+			if locked != tt.wantLocked {
+				t.Errorf("Lock.Verify() locked = %v, want %v", locked, tt.wantLocked)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Lock.Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLock_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := l.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Save() wrote an unchanged lockfile: %v", err)
+	}
+
+	state := watcher.RemoteFileState{URL: testURL, ETag: `"v1"`, LastModified: "Wed, 01 May 2024 12:00:00 GMT"}
+	l.Set(NewEntry(state, []byte("# Rules"), fetchedAt))
+	l.Set(NewEntry(watcher.RemoteFileState{URL: "https://example.com/a.md"}, []byte("a"), fetchedAt))
+	if err := l.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	entry, ok := loaded.Get(testURL)
+	if !ok {
+		t.Fatalf("Load() lost entry for %s", testURL)
+	}
+	want := Entry{
+		URL:          testURL,
+		ETag:         `"v1"`,
+		LastModified: "Wed, 01 May 2024 12:00:00 GMT",
+		SHA256:       Hash([]byte("# Rules")),
+		FetchedAt:    fetchedAt,
+	}
+	if entry != want {
+		t.Errorf("Load() entry = %+v, want %+v", entry, want)
+	}
+	if loaded.Changed() {
+		t.Errorf("Load() lock is marked as changed")
+	}
+
+	loaded.Prune([]string{testURL})
+	if _, ok := loaded.Get("https://example.com/a.md"); ok || !loaded.Changed() {
+		t.Errorf("Prune() kept an unused entry")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "empty remotes", input: `{"version":1,"remotes":[]}`},
+		{name: "entry", input: `{"version":1,"remotes":[{"url":"` + testURL + `","sha256":"abc","fetched_at":"2024-05-01T12:00:00Z"}]}`},
+		{name: "unsupported version", input: `{"version":2,"remotes":[]}`, wantErr: true},
+		{name: "missing hash", input: `{"version":1,"remotes":[{"url":"` + testURL + `"}]}`, wantErr: true},
+		{name: "invalid JSON", input: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.input)); (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
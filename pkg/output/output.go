@@ -0,0 +1,359 @@
+// Package output provides safe writing of the combined output file
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// defaultPerm is the permission of newly created output files
+const defaultPerm = 0644
+
+// tempPattern returns the os.CreateTemp pattern for temporary files of the output at path
+func tempPattern(path string) string {
+	return "." + filepath.Base(path) + ".tmp-*"
+}
+
+// IsTemp reports whether file is a temporary file created while writing the output at path.
+// When path is a symbolic link, temporary files are created next to the file it points to.
+func IsTemp(path, file string) bool {
+	if resolved, err := resolveLinks(path); err == nil && resolved != path && isTemp(resolved, file) {
+		return true
+	}
+	return isTemp(path, file)
+}
+
+// isTemp reports whether file is named and placed like a temporary file of the output at path
+func isTemp(path, file string) bool {
+	fileDir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return false
+	}
+	outputDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil || fileDir != outputDir {
+		return false
+	}
+	matched, err := filepath.Match(tempPattern(path), filepath.Base(file))
+	return err == nil && matched
+}
+
+// Unchanged reports whether the file at path already holds exactly data.
+// A missing file is never unchanged.
+func Unchanged(path string, data []byte) (bool, error) {
+	return unchangedFrom(path, writeData(data))
+}
+
+// unchangedFrom reports whether the file at path already holds exactly what render writes.
+// Rendering stops at the first difference.
+func unchangedFrom(path string, render func(w io.Writer) error) (bool, error) {
+	existing, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer existing.Close()
+
+	c := &compareWriter{r: bufio.NewReader(existing)}
+	if err := render(c); err != nil {
+		if errors.Is(err, errDiffers) {
+			return false, nil
+		}
+		return false, err
+	}
+	if c.err != nil {
+		return false, fmt.Errorf("reading %s: %w", path, c.err)
+	}
+	// The existing file must not be longer than the output
+	if _, err := c.r.ReadByte(); err != io.EOF {
+		if err != nil {
+			return false, fmt.Errorf("reading %s: %w", path, err)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// errDiffers stops rendering once the output differs from the existing file
+var errDiffers = errors.New("output differs from the existing file")
+
+// compareWriter compares everything written to it with the content read from r
+type compareWriter struct {
+	r   *bufio.Reader
+	buf [4096]byte
+	// err is the error reading r, other than reaching its end
+	err error
+}
+
+// Write returns errDiffers when p does not continue the content of r
+func (c *compareWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), len(c.buf))
+		if _, err := io.ReadFull(c.r, c.buf[:n]); err != nil {
+			if err != io.EOF && err != io.ErrUnexpectedEOF {
+				c.err = err
+			}
+			return written, errDiffers
+		}
+		if !bytes.Equal(c.buf[:n], p[:n]) {
+			return written, errDiffers
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// writeData returns a render function writing data
+func writeData(data []byte) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}
+}
+
+// Write replaces the file at path with data atomically.
+// The data is written to a temporary file in the same directory, synced and renamed into place,
+// so readers see either the old or the new content, never a partial file.
+// Nothing is written when the file already holds identical content; the returned bool reports whether it was written.
+func Write(path string, data []byte) (bool, error) {
+	return WriteFrom(path, writeData(data))
+}
+
+// WriteFrom replaces the file at path with the output of render atomically, like Write,
+// without holding the output in memory.
+// render is called once. Its output is compared with the existing file as it is written,
+// and a temporary file is only created from the first difference on.
+// Errors returned by render are returned as they are.
+func WriteFrom(path string, render func(w io.Writer) error) (bool, error) {
+	// Keep the permissions of an existing file
+	perm := os.FileMode(defaultPerm)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return writeFrom(path, render, perm)
+}
+
+// WriteMode replaces the file at path with data atomically like Write, giving it the permissions perm.
+// The permissions of an existing file are changed to perm even when its content is unchanged.
+func WriteMode(path string, data []byte, perm os.FileMode) (bool, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != perm {
+		if err := os.Chmod(path, perm); err != nil {
+			return false, fmt.Errorf("setting permissions: %w", err)
+		}
+	}
+	return writeFrom(path, writeData(data), perm)
+}
+
+// resolveLinks returns the file that path refers to after following symbolic links.
+// A link to a file that does not exist yet resolves to the path of that file,
+// and a path without links is returned as it is.
+func resolveLinks(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("resolving %s: %w", path, err)
+	}
+
+	// The path itself is missing, or it is a link whose target is missing
+	target, err := os.Readlink(path)
+	if err != nil {
+		return path, nil
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return resolveLinks(target)
+}
+
+// writeFrom replaces the file at path with the output of render unless it is unchanged,
+// giving a new file the permissions perm.
+// When path is a symbolic link, the file it points to is replaced and the link is kept.
+func writeFrom(path string, render func(w io.Writer) error, perm os.FileMode) (bool, error) {
+	path, err := resolveLinks(path)
+	if err != nil {
+		return false, err
+	}
+	existing, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("reading %s: %w", path, err)
+	}
+	d := &divergeWriter{path: path, existing: existing}
+	if existing != nil {
+		defer existing.Close()
+		d.r = bufio.NewReader(existing)
+	}
+	// Remove the temporary file on any failure; after a successful rename it no longer exists
+	defer d.discard()
+
+	if err := render(d); err != nil {
+		if d.err != nil {
+			return false, d.err
+		}
+		return false, err
+	}
+	if d.tmp == nil {
+		// The existing file must not be longer than the output
+		unchanged, err := d.atEnd()
+		if err != nil {
+			return false, err
+		}
+		if unchanged {
+			return false, nil
+		}
+		if err := d.diverge(); err != nil {
+			return false, err
+		}
+	}
+
+	tmp, tmpPath := d.tmp, d.tmp.Name()
+	if err := d.w.Flush(); err != nil {
+		return false, fmt.Errorf("writing temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return false, fmt.Errorf("syncing temporary file: %w", err)
+	}
+	d.tmp = nil
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return false, fmt.Errorf("closing temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return false, fmt.Errorf("setting permissions: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return false, fmt.Errorf("renaming temporary file to %s: %w", path, err)
+	}
+
+	syncDir(filepath.Dir(path))
+	return true, nil
+}
+
+// divergeWriter compares everything written to it with the existing file
+// and switches to writing a temporary file at the first difference.
+// The matching prefix is copied from the existing file, so the output is rendered only once.
+type divergeWriter struct {
+	path     string
+	existing *os.File
+	// r reads the existing file while the output matches it; nil without an existing file
+	r   *bufio.Reader
+	buf [4096]byte
+	// matched is the length of the prefix that equals the existing file
+	matched int64
+	tmp     *os.File
+	w       *bufio.Writer
+	// err is the error of the writer itself, as opposed to one of render
+	err error
+}
+
+// Write compares p with the existing file, or writes it to the temporary file after a difference
+func (d *divergeWriter) Write(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if d.w != nil {
+		return d.write(p)
+	}
+
+	written := 0
+	for len(p) > 0 && d.r != nil {
+		n := min(len(p), len(d.buf))
+		read, err := io.ReadFull(d.r, d.buf[:n])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			d.err = fmt.Errorf("reading %s: %w", d.path, err)
+			return written, d.err
+		}
+		same := 0
+		for same < read && d.buf[same] == p[same] {
+			same++
+		}
+		d.matched += int64(same)
+		written += same
+		p = p[same:]
+		if same < n {
+			break
+		}
+	}
+	if len(p) == 0 {
+		return written, nil
+	}
+
+	if err := d.diverge(); err != nil {
+		return written, err
+	}
+	n, err := d.write(p)
+	return written + n, err
+}
+
+// write writes p to the temporary file
+func (d *divergeWriter) write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	if err != nil {
+		d.err = fmt.Errorf("writing temporary file: %w", err)
+		return n, d.err
+	}
+	return n, nil
+}
+
+// diverge creates the temporary file and copies the matching prefix of the existing file into it
+func (d *divergeWriter) diverge() error {
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), tempPattern(d.path))
+	if err != nil {
+		d.err = fmt.Errorf("creating temporary file: %w", err)
+		return d.err
+	}
+	d.tmp = tmp
+	d.w = bufio.NewWriter(tmp)
+	d.r = nil
+	if d.matched > 0 {
+		if _, err := io.Copy(d.w, io.NewSectionReader(d.existing, 0, d.matched)); err != nil {
+			d.err = fmt.Errorf("copying %s: %w", d.path, err)
+			return d.err
+		}
+	}
+	return nil
+}
+
+// atEnd reports whether the whole existing file matched the output
+func (d *divergeWriter) atEnd() (bool, error) {
+	if d.r == nil {
+		return false, nil
+	}
+	if _, err := d.r.ReadByte(); err != io.EOF {
+		if err != nil {
+			return false, fmt.Errorf("reading %s: %w", d.path, err)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// discard removes the temporary file unless it was renamed into place
+func (d *divergeWriter) discard() {
+	if d.tmp != nil {
+		d.tmp.Close()
+		os.Remove(d.tmp.Name())
+	}
+}
+
+// syncDir flushes a directory entry change to disk.
+// Failures are ignored because not every platform or file system supports syncing directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
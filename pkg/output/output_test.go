@@ -0,0 +1,361 @@
+//go:build small
+
+package output
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    *string
+		data        string
+		wantWritten bool
+	}{
+		{
+			name:        "new file",
+			existing:    nil,
+			data:        "# Combined",
+			wantWritten: true,
+		},
+		{
+			name:        "changed content",
+			existing:    strPtr("# Old"),
+			data:        "# New",
+			wantWritten: true,
+		},
+		{
+			name:        "identical content is skipped",
+			existing:    strPtr("# Same"),
+			data:        "# Same",
+			wantWritten: false,
+		},
+		{
+			name:        "empty content replaces file",
+			existing:    strPtr("# Old"),
+			data:        "",
+			wantWritten: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "output.md")
+			oldTime := time.Now().Add(-time.Hour)
+			if tt.existing != nil {
+				if err := os.WriteFile(path, []byte(*tt.existing), 0600); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(path, oldTime, oldTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			written, err := Write(path, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("Write() written = %v, want %v", written, tt.wantWritten)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.data {
+				t.Errorf("file content = %q, want %q", got, tt.data)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing != nil {
+				if info.Mode().Perm() != 0600 {
+					t.Errorf("file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+				}
+				if !tt.wantWritten && !info.ModTime().Equal(oldTime) {
+					t.Errorf("skipped write changed mtime to %v", info.ModTime())
+				}
+			}
+
+			// No temporary files are left behind
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("directory has %d entries, want 1", len(entries))
+			}
+		})
+	}
+}
+
+func TestWrite_MissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "output.md")
+	if _, err := Write(path, []byte("# Combined")); err == nil {
+		t.Error("Write() expected error but got nil")
+	}
+}
+
+func TestWrite_Symlink(t *testing.T) {
+	tests := []struct {
+		name string
+		// link is the target of the output link; a leading slash makes it absolute within the temporary directory
+		link     string
+		existing *string
+		want     bool
+	}{
+		{name: "relative link", link: "shared/output.md", existing: strPtr("# Old"), want: true},
+		{name: "absolute link", link: "/shared/output.md", existing: strPtr("# Old"), want: true},
+		{name: "link to a missing file", link: "shared/output.md", want: true},
+		{name: "identical content", link: "shared/output.md", existing: strPtr("# Combined"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "shared"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			target := filepath.Join(dir, "shared", "output.md")
+			if tt.existing != nil {
+				if err := os.WriteFile(target, []byte(*tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			link := tt.link
+			if strings.HasPrefix(link, "/") {
+				link = filepath.Join(dir, link)
+			}
+			path := filepath.Join(dir, "output.md")
+			if err := os.Symlink(link, path); err != nil {
+				t.Skipf("symbolic links unavailable: %v", err)
+			}
+
+			written, err := Write(path, []byte("# Combined"))
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if written != tt.want {
+				t.Errorf("Write() written = %v, want %v", written, tt.want)
+			}
+
+			info, err := os.Lstat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("output is no longer a symbolic link: %v", info.Mode())
+			}
+			got, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "# Combined" {
+				t.Errorf("target content = %q, want %q", got, "# Combined")
+			}
+			for _, d := range []string{dir, filepath.Join(dir, "shared")} {
+				entries, err := os.ReadDir(d)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, e := range entries {
+					if strings.Contains(e.Name(), ".tmp-") {
+						t.Errorf("temporary file left behind: %s", e.Name())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFrom(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    *string
+		data        string
+		wantWritten bool
+	}{
+		{
+			name:        "identical content",
+			existing:    strPtr("# Same"),
+			data:        "# Same",
+			wantWritten: false,
+		},
+		{
+			name:        "existing file is longer",
+			existing:    strPtr("# Same\nmore"),
+			data:        "# Same",
+			wantWritten: true,
+		},
+		{
+			name:        "existing file is a prefix",
+			existing:    strPtr("# Same"),
+			data:        "# Same\nmore",
+			wantWritten: true,
+		},
+		{
+			name:        "content larger than the comparison buffer",
+			existing:    strPtr(strings.Repeat("a", 10000) + "b"),
+			data:        strings.Repeat("a", 10000) + "c",
+			wantWritten: true,
+		},
+		{
+			name:        "difference within a write",
+			existing:    strPtr("# Old content"),
+			data:        "# New content",
+			wantWritten: true,
+		},
+		{
+			name:        "no existing file",
+			existing:    nil,
+			data:        "# New",
+			wantWritten: true,
+		},
+		{
+			name:        "empty output",
+			existing:    strPtr("# Old"),
+			data:        "",
+			wantWritten: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "output.md")
+			if tt.existing != nil {
+				if err := os.WriteFile(path, []byte(*tt.existing), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			renders := 0
+			written, err := WriteFrom(path, func(w io.Writer) error {
+				renders++
+				// Write in small pieces like a formatter streaming sections
+				for _, line := range strings.SplitAfter(tt.data, "a") {
+					if _, err := io.WriteString(w, line); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("WriteFrom() error = %v", err)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("WriteFrom() written = %v, want %v", written, tt.wantWritten)
+			}
+			if renders != 1 {
+				t.Errorf("render called %d times, want 1", renders)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.data {
+				t.Errorf("file content = %q, want %q", got, tt.data)
+			}
+			// No temporary files are left behind
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("directory has %d entries, want 1", len(entries))
+			}
+		})
+	}
+}
+
+func TestWriteFrom_RenderError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "output.md")
+	if err := os.WriteFile(path, []byte("# Old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	renderErr := errors.New("render failed")
+	_, err := WriteFrom(path, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "# New"); err != nil {
+			return err
+		}
+		return renderErr
+	})
+	if !errors.Is(err, renderErr) {
+		t.Fatalf("WriteFrom() error = %v, want %v", err, renderErr)
+	}
+
+	// The existing file is kept and no temporary files are left behind
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "# Old" {
+		t.Errorf("file content = %q, want %q", got, "# Old")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1", len(entries))
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestIsTemp(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		file string
+		want bool
+	}{
+		{name: "temporary file", path: "docs/output.md", file: "docs/.output.md.tmp-123", want: true},
+		{name: "output file itself", path: "docs/output.md", file: "docs/output.md", want: false},
+		{name: "other directory", path: "docs/output.md", file: "src/.output.md.tmp-123", want: false},
+		{name: "other output", path: "docs/output.md", file: "docs/.other.md.tmp-123", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTemp(tt.path, tt.file); got != tt.want {
+				t.Errorf("IsTemp(%q, %q) = %v, want %v", tt.path, tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTemp_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "shared"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "output.md")
+	if err := os.Symlink(filepath.Join("shared", "combined.md"), path); err != nil {
+		t.Skipf("symbolic links unavailable: %v", err)
+	}
+
+	// Temporary files are created next to the file the link points to
+	if file := filepath.Join(dir, "shared", ".combined.md.tmp-123"); !IsTemp(path, file) {
+		t.Errorf("IsTemp(%q, %q) = false, want true", path, file)
+	}
+	if file := filepath.Join(dir, ".output.md.tmp-123"); !IsTemp(path, file) {
+		t.Errorf("IsTemp(%q, %q) = false, want true", path, file)
+	}
+}
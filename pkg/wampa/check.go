@@ -0,0 +1,37 @@
+package wampa
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/toms74209200/wampa/pkg/diff"
+	"github.com/toms74209200/wampa/pkg/formatter"
+)
+
+// checkOutputs builds every output in memory and compares it with the file on disk.
+// A unified diff is written to w for each output that is out of date,
+// and the paths of those outputs are returned.
+func checkOutputs(w io.Writer, targets []*target, sections map[string]formatter.Section, stdin *formatter.Section, root string) ([]string, error) {
+	var stale []string
+	for _, t := range targets {
+		expected, err := t.render(sections, stdin, root)
+		if err != nil {
+			return nil, fmt.Errorf("output file %s: %w", t.output, err)
+		}
+
+		// A missing output file is compared as empty
+		actual, err := os.ReadFile(t.output)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read output file %s: %w", t.output, err)
+		}
+
+		d := diff.Unified(t.output, t.output+" (expected)", string(actual), expected, diff.DefaultContext)
+		if d == "" {
+			continue
+		}
+		fmt.Fprint(w, d)
+		stale = append(stale, t.output)
+	}
+	return stale, nil
+}
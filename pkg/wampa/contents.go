@@ -0,0 +1,81 @@
+package wampa
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/toms74209200/wampa/pkg/formatter"
+)
+
+// maxCachedSize is the largest local input whose content is kept in memory between rebuilds.
+// Larger inputs keep only their metadata and are streamed from disk while an output is written.
+const maxCachedSize = 1 * MB
+
+// localContents caches the sections of local input files keyed by absolute path.
+// An entry is dropped when the watcher reports a change to its file,
+// so files that did not change are neither read nor stat'ed again.
+type localContents struct {
+	sections map[string]formatter.Section
+}
+
+// newLocalContents creates an empty cache
+func newLocalContents() *localContents {
+	return &localContents{sections: make(map[string]formatter.Section)}
+}
+
+// contentKey returns the absolute path of a local file, as reported by the local watcher
+func contentKey(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
+
+// section returns the section of a local input file, creating it only when it is not cached.
+// The content of a file up to maxCachedSize is read at once; larger files are opened when the output is written.
+// Placeholders for missing files are not cached.
+func (c *localContents) section(file string, placeholder bool) (formatter.Section, error) {
+	key := contentKey(file)
+	if section, ok := c.sections[key]; ok {
+		return section, nil
+	}
+
+	section, err := localSection(file, placeholder)
+	if err != nil {
+		return formatter.Section{}, err
+	}
+	if section.Open == nil {
+		return section, nil
+	}
+	if section.Size <= maxCachedSize {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return formatter.Section{}, err
+		}
+		section.Content = string(data)
+		section.Size = int64(len(data))
+		section.Open = nil
+	}
+	c.sections[key] = section
+	return section, nil
+}
+
+// invalidate drops the cached section of a changed file
+func (c *localContents) invalidate(path string) {
+	delete(c.sections, contentKey(path))
+}
+
+// retain drops the cached sections of files that are no longer inputs
+func (c *localContents) retain(files []string) {
+	keep := make(map[string]bool, len(files))
+	for _, file := range files {
+		if !isRemote(file) {
+			keep[contentKey(file)] = true
+		}
+	}
+	for key := range c.sections {
+		if !keep[key] {
+			delete(c.sections, key)
+		}
+	}
+}
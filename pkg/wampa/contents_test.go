@@ -0,0 +1,83 @@
+//go:build small
+
+package wampa
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toms74209200/wampa/pkg/formatter"
+)
+
+// TestLocalContents tests reusing the sections of local inputs until their files change
+func TestLocalContents(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.md")
+	large := filepath.Join(dir, "large.md")
+	if err := os.WriteFile(small, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, []byte(strings.Repeat("a", maxCachedSize+1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newLocalContents()
+	section, err := c.section(small, false)
+	if err != nil {
+		t.Fatalf("section() error = %v", err)
+	}
+	if section.Content != "v1" || section.Open != nil {
+		t.Errorf("section() = %+v, want content %q held in memory", section, "v1")
+	}
+
+	// The cached section is used until the change is reported
+	if err := os.WriteFile(small, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if section, _ := c.section(small, false); section.Content != "v1" {
+		t.Errorf("section() before invalidate content = %q, want %q", section.Content, "v1")
+	}
+	c.invalidate(small)
+	if section, _ := c.section(small, false); section.Content != "v2" {
+		t.Errorf("section() after invalidate content = %q, want %q", section.Content, "v2")
+	}
+
+	// Large files are streamed from disk
+	section, err = c.section(large, false)
+	if err != nil {
+		t.Fatalf("section() error = %v", err)
+	}
+	if section.Open == nil || section.Size != maxCachedSize+1 {
+		t.Fatalf("section() = Size %d, Open %v, want a streamed section", section.Size, section.Open != nil)
+	}
+	r, err := section.Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || len(data) != maxCachedSize+1 {
+		t.Errorf("Open() read %d bytes, %v, want %d", len(data), err, maxCachedSize+1)
+	}
+
+	// Placeholders for missing files are not cached
+	missing := filepath.Join(dir, "missing.md")
+	if section, err := c.section(missing, true); err != nil || section.Content != formatter.MissingContent {
+		t.Errorf("section() of missing file = %+v, %v, want placeholder", section, err)
+	}
+	if _, ok := c.sections[contentKey(missing)]; ok {
+		t.Error("placeholder was cached")
+	}
+
+	// Files that are no longer inputs are dropped
+	c.retain([]string{small, "https://example.com/remote.md"})
+	if _, ok := c.sections[contentKey(large)]; ok {
+		t.Error("section of a removed input was kept")
+	}
+	if _, ok := c.sections[contentKey(small)]; !ok {
+		t.Error("section of a remaining input was dropped")
+	}
+}
@@ -0,0 +1,122 @@
+package wampa
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/toms74209200/wampa/pkg/config"
+	"github.com/toms74209200/wampa/pkg/formatter"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+// newFormatter creates the formatter selected for an output.
+// A relative template file is read from root, the directory of the configuration file.
+func newFormatter(o config.Output, root string) (formatter.Formatter, error) {
+	if o.Format() != formatter.FormatTemplate {
+		return formatter.New(o.Format())
+	}
+
+	text := o.Template
+	if o.TemplateFile != "" {
+		path := o.TemplateFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file: %w", err)
+		}
+		text = string(data)
+	}
+	return formatter.NewTemplateFormatter(text)
+}
+
+// localSection creates the section of a local input file without reading it;
+// the file is opened when the output is written.
+// With placeholder, a file that does not exist gets formatter.MissingContent as its content.
+func localSection(file string, placeholder bool) (formatter.Section, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		if placeholder && errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Input file not found, using a placeholder: %s", file)
+			return formatter.Section{
+				Path:    file,
+				Content: formatter.MissingContent,
+				Source:  formatter.SourceLocal,
+				Size:    int64(len(formatter.MissingContent)),
+			}, nil
+		}
+		return formatter.Section{}, err
+	}
+	if info.IsDir() {
+		return formatter.Section{}, fmt.Errorf("%s is a directory", file)
+	}
+
+	return formatter.Section{
+		Path:    file,
+		Source:  formatter.SourceLocal,
+		ModTime: info.ModTime(),
+		Size:    info.Size(),
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(file)
+		},
+	}, nil
+}
+
+// remoteSection creates the section of a remote input from the content held by the remote watcher.
+// It reports false when no accepted content of the URL is known.
+func remoteSection(url string, rw *watcher.RemoteWatcher) (formatter.Section, bool) {
+	content, ok := rw.Content(url)
+	if !ok {
+		return formatter.Section{}, false
+	}
+
+	section := formatter.Section{
+		Path:   url,
+		Source: formatter.SourceRemote,
+		Size:   int64(len(content)),
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		},
+	}
+	if state, ok := rw.State(url); ok && state.LastModified != "" {
+		if t, err := http.ParseTime(state.LastModified); err == nil {
+			section.ModTime = t
+		}
+	}
+	return section, true
+}
+
+// stdinSection creates the section of the content read from standard input
+func stdinSection(content string) formatter.Section {
+	return formatter.Section{
+		Path:    formatter.StdinPath,
+		Content: content,
+		Source:  formatter.SourceStdin,
+		Size:    int64(len(content)),
+	}
+}
+
+// buildSections returns the sections of the inputs that have one, keeping the order of inputs
+// and naming them with the path style relative to root.
+// The stdin section, when given, is placed first; it is kept apart from sections,
+// which is keyed by input path, so that an input file named like it is not replaced.
+func buildSections(inputs []string, sections map[string]formatter.Section, stdin *formatter.Section, style formatter.PathStyle, root string) []formatter.Section {
+	result := make([]formatter.Section, 0, len(inputs)+1)
+	if stdin != nil {
+		result = append(result, *stdin)
+	}
+	for _, input := range inputs {
+		if section, ok := sections[input]; ok {
+			result = append(result, section)
+		}
+	}
+	return formatter.WithPathStyle(result, style, root)
+}
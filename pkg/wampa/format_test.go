@@ -0,0 +1,84 @@
+//go:build small
+
+package wampa
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toms74209200/wampa/pkg/config"
+	"github.com/toms74209200/wampa/pkg/formatter"
+)
+
+// TestNewFormatter_TemplateFile tests reading template files relative to the configuration file
+func TestNewFormatter_TemplateFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "output.tmpl"), []byte("{{.Count}} files"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		templateFile string
+		wantErr      bool
+	}{
+		{name: "設定ファイルからの相対パス", templateFile: "output.tmpl"},
+		{name: "絶対パス", templateFile: filepath.Join(root, "output.tmpl")},
+		{name: "存在しないファイル", templateFile: "missing.tmpl", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newFormatter(config.Output{TemplateFile: tt.templateFile}, root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newFormatter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := formatter.FormatSections(f, []formatter.Section{{Path: "a.md", Content: "a"}})
+			if err != nil {
+				t.Fatalf("FormatSections() error = %v", err)
+			}
+			if got != "1 files" {
+				t.Errorf("FormatSections() = %q, want %q", got, "1 files")
+			}
+		})
+	}
+}
+
+// TestBuildSections tests the order of sections and that the stdin section is kept apart from input files
+func TestBuildSections(t *testing.T) {
+	stdin := stdinSection("piped")
+	sections := map[string]formatter.Section{
+		"a.md":              {Path: "a.md", Content: "a", Source: formatter.SourceLocal},
+		formatter.StdinPath: {Path: formatter.StdinPath, Content: "file", Source: formatter.SourceLocal},
+	}
+
+	tests := []struct {
+		name   string
+		inputs []string
+		stdin  *formatter.Section
+		want   []string
+	}{
+		{name: "入力ファイルの順序を保つ", inputs: []string{formatter.StdinPath, "a.md"}, want: []string{"file", "a"}},
+		{name: "標準入力を先頭に置く", inputs: []string{"a.md"}, stdin: &stdin, want: []string{"piped", "a"}},
+		{name: "stdinという名前のファイルと標準入力を区別する", inputs: []string{"a.md", formatter.StdinPath}, stdin: &stdin, want: []string{"piped", "a", "file"}},
+		{name: "セクションのない入力を除く", inputs: []string{"missing.md", "a.md"}, want: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildSections(tt.inputs, sections, tt.stdin, formatter.PathStyleBase, ".")
+			if len(got) != len(tt.want) {
+				t.Fatalf("buildSections() = %v, want contents %v", got, tt.want)
+			}
+			for i, section := range got {
+				if section.Content != tt.want[i] {
+					t.Errorf("buildSections()[%d].Content = %q, want %q", i, section.Content, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package wampa
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/toms74209200/wampa/pkg/glob"
+	outputfile "github.com/toms74209200/wampa/pkg/output"
+)
+
+// isRemote reports whether an input file is an HTTP or HTTPS URL
+func isRemote(file string) bool {
+	u, err := url.Parse(file)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// splitInputs separates local file paths from remote URLs, keeping their order
+func splitInputs(files []string) (local, remote []string) {
+	for _, file := range files {
+		if isRemote(file) {
+			remote = append(remote, file)
+		} else {
+			local = append(local, file)
+		}
+	}
+	return local, remote
+}
+
+// loadIgnore combines the .wampaignore file at root with the configured exclude patterns.
+// Exclude patterns come last so that they take precedence.
+func loadIgnore(root string, exclude []string) (*glob.Ignore, error) {
+	var patterns []string
+
+	data, err := os.ReadFile(filepath.Join(root, glob.IgnoreFileName))
+	if err == nil {
+		patterns = glob.ParseIgnoreFile(data)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", glob.IgnoreFileName, err)
+	}
+	patterns = append(patterns, exclude...)
+
+	ignore, err := glob.NewIgnore(root, patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+	return ignore, nil
+}
+
+// resolveInputs expands glob patterns and directories in the configured input entries.
+// Entries keep their configured order, and files matched by one entry are sorted lexically.
+// A file matched by several entries appears only once, and output files are never inputs.
+// Expanded files excluded by ignore are dropped; literal file entries are always kept.
+// It also returns the directories that may receive new matching files.
+func resolveInputs(entries []string, outputFiles []string, ignore *glob.Ignore) (inputs []string, dirs []string, err error) {
+	seen := make(map[string]bool)
+	for _, outputFile := range outputFiles {
+		if abs, err := filepath.Abs(outputFile); err == nil {
+			seen[abs] = true
+		}
+	}
+	isTemp := func(file string) bool {
+		for _, outputFile := range outputFiles {
+			if outputfile.IsTemp(outputFile, file) {
+				return true
+			}
+		}
+		return false
+	}
+
+	add := func(file string) {
+		key := file
+		if !isRemote(file) {
+			if abs, err := filepath.Abs(file); err == nil {
+				key = abs
+			}
+		}
+		if seen[key] || isTemp(file) {
+			return
+		}
+		seen[key] = true
+		inputs = append(inputs, file)
+	}
+
+	for _, entry := range entries {
+		if isRemote(entry) {
+			add(entry)
+			continue
+		}
+
+		pattern := entry
+		if !glob.HasMeta(entry) {
+			info, err := os.Stat(entry)
+			if err != nil || !info.IsDir() {
+				// Literal files are kept even when missing so that read errors are reported
+				add(entry)
+				continue
+			}
+			pattern = filepath.Join(entry, glob.DoubleStar)
+		}
+
+		files, searched, err := glob.Expand(pattern, ignore)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to expand %s: %w", entry, err)
+		}
+		for _, file := range files {
+			add(file)
+		}
+		dirs = append(dirs, searched...)
+	}
+
+	return inputs, dirs, nil
+}
+
+// equalInputs reports whether two resolved input lists are identical
+func equalInputs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package wampa
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/toms74209200/wampa/pkg/lock"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+// applyLock checks the content of a remote file against the lockfile.
+// URLs that are not locked yet are pinned to their content.
+// With update, content that differs from the lockfile replaces the locked entry instead of being refused.
+func applyLock(lk *lock.Lock, content []byte, state watcher.RemoteFileState, update bool) error {
+	locked, err := lk.Verify(state.URL, content)
+	switch {
+	case !locked:
+		lk.Set(lock.NewEntry(state, content, time.Now()))
+		log.Printf("Pinned %s in %s", state.URL, lock.FileName)
+	case err != nil && update:
+		lk.Set(lock.NewEntry(state, content, time.Now()))
+		log.Printf("Updated %s in %s", state.URL, lock.FileName)
+	case err != nil:
+		return fmt.Errorf("%w; run with --update-lock or `wampa update` to accept it", err)
+	}
+	return nil
+}
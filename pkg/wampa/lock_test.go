@@ -0,0 +1,109 @@
+//go:build small
+
+package wampa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/toms74209200/wampa/pkg/cache"
+	"github.com/toms74209200/wampa/pkg/lock"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+const testLockURL = "https://example.com/rules.md"
+
+// loadedLock returns a lock as loaded from a lockfile that pins url to content
+func loadedLock(t *testing.T, url, content string) *lock.Lock {
+	t.Helper()
+	lk := lock.New()
+	if content != "" {
+		lk.Set(lock.NewEntry(watcher.RemoteFileState{URL: url, ETag: `"` + content + `"`}, []byte(content), time.Now()))
+	}
+	data, err := lk.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := lock.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func TestApplyLock(t *testing.T) {
+	tests := []struct {
+		name   string
+		locked string
+		update bool
+		// want is the content pinned in the lockfile afterwards
+		want    string
+		wantErr bool
+	}{
+		{name: "未登録のURLを固定する", want: "v2"},
+		{name: "ロックと一致する内容を受け入れる", locked: "v2", want: "v2"},
+		{name: "ロックと異なる内容を拒否する", locked: "v1", want: "v1", wantErr: true},
+		{name: "updateではロックを更新する", locked: "v1", update: true, want: "v2"},
+		{name: "updateでも一致する内容はそのまま", locked: "v2", update: true, want: "v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lk := loadedLock(t, testLockURL, tt.locked)
+			state := watcher.RemoteFileState{URL: testLockURL, ETag: `"v2"`}
+			err := applyLock(lk, []byte("v2"), state, tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyLock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			entry, ok := lk.Get(testLockURL)
+			if !ok {
+				t.Fatalf("%s is not locked", testLockURL)
+			}
+			if entry.SHA256 != lock.Hash([]byte(tt.want)) {
+				t.Errorf("locked hash = %s, want hash of %q", entry.SHA256, tt.want)
+			}
+			if entry.ETag != `"`+tt.want+`"` {
+				t.Errorf("locked ETag = %s, want %q", entry.ETag, `"`+tt.want+`"`)
+			}
+			if changed := tt.locked != tt.want; lk.Changed() != changed {
+				t.Errorf("Changed() = %v, want %v", lk.Changed(), changed)
+			}
+		})
+	}
+}
+
+// TestFetchRemotes_Update tests that `wampa update` does not pin cached copies of files it cannot fetch
+func TestFetchRemotes_Update(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	url := server.URL + "/rules.md"
+
+	f := newTestFetcher(t)
+	f.live = true
+	if err := f.cache.Put(cache.Entry{State: watcher.RemoteFileState{URL: url}, Content: []byte("cached"), FetchedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	lk := loadedLock(t, url, "old")
+	rw, err := watcher.NewRemoteWatcher(http.DefaultClient, maxFileSize, func(string) time.Duration { return time.Minute })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+
+	failed, _ := fetchRemotes(context.Background(), f, rw, lk, []string{url}, 1, true)
+	if len(failed) != 1 || failed[0] != url {
+		t.Errorf("fetchRemotes() failed = %v, want [%s]", failed, url)
+	}
+	if entry, _ := lk.Get(url); entry.SHA256 != lock.Hash([]byte("old")) {
+		t.Errorf("lockfile was updated with the cached copy")
+	}
+	if lk.Changed() {
+		t.Errorf("Changed() = true, want false")
+	}
+}
@@ -0,0 +1,152 @@
+package wampa
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/toms74209200/wampa/pkg/config"
+	"github.com/toms74209200/wampa/pkg/glob"
+	"github.com/toms74209200/wampa/pkg/lock"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+// applyCLIOptions overrides the settings of a configuration file with command line options
+func applyCLIOptions(cfg *config.Config, opts *config.CLIOptions) {
+	if len(opts.InputFiles) > 0 {
+		cfg.InputFiles = opts.InputFiles
+	}
+	if opts.OutputFile != "" {
+		// A single output from the command line replaces the configured outputs
+		cfg.OutputFile = opts.OutputFile
+		cfg.Outputs = nil
+	}
+	if opts.Stdin {
+		cfg.Stdin = true
+	}
+	if opts.OutputFormat != "" {
+		cfg.OutputFormat = opts.OutputFormat
+	}
+}
+
+// loadConfigFile reads and parses a configuration file, applies the command line options and validates the result
+func loadConfigFile(path string, opts *config.CLIOptions) (*config.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config file: %w", err)
+	}
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	applyCLIOptions(cfg, opts)
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// prepareTargets creates the targets of a configuration and resolves their inputs.
+// It also returns the exclude patterns and the files written by wampa, which are never inputs.
+func prepareTargets(cfg *config.Config, projectRoot string) ([]*target, *glob.Ignore, []string, error) {
+	targets, err := newTargets(cfg, projectRoot)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("creating formatter: %w", err)
+	}
+
+	// Expand glob patterns and directories, dropping excluded files
+	ignore, err := loadIgnore(projectRoot, cfg.Exclude)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("loading exclude patterns: %w", err)
+	}
+
+	// The lockfile pins remote inputs and is never an input itself
+	generated := append(outputFiles(targets), filepath.Join(projectRoot, lock.FileName))
+	for _, t := range targets {
+		if _, err := t.resolve(generated, ignore); err != nil {
+			return nil, nil, nil, fmt.Errorf("resolving input files: %w", err)
+		}
+	}
+	return targets, ignore, generated, nil
+}
+
+// restartOnlyChanged reports whether settings that are applied only at startup differ between two configurations
+func restartOnlyChanged(prev, next *config.Config) bool {
+	return !reflect.DeepEqual(prev.HTTP, next.HTTP) ||
+		prev.WatcherOptions() != next.WatcherOptions() ||
+		!reflect.DeepEqual(prev.HostHeaders(), next.HostHeaders()) ||
+		!reflect.DeepEqual(prev.URLHeaders(), next.URLHeaders())
+}
+
+// restartRemoteWatcher replaces the remote watcher with one that uses the poll intervals of cfg.
+// The known content of urls is carried over, so that polling continues with conditional requests.
+// Polling does not start until watchRemote is called on the new watcher.
+func restartRemoteWatcher(old *watcher.RemoteWatcher, client watcher.HTTPClient, cfg *config.Config, urls []string) (*watcher.RemoteWatcher, error) {
+	rw, err := watcher.NewRemoteWatcher(client, maxFileSize, cfg.PollInterval)
+	if err != nil {
+		return nil, err
+	}
+	for _, url := range urls {
+		content, ok := old.Content(url)
+		if !ok {
+			continue
+		}
+		state, _ := old.State(url)
+		rw.SetState(content, state)
+	}
+	if err := old.Close(); err != nil {
+		log.Printf("Error closing remote watcher: %v", err)
+	}
+	return rw, nil
+}
+
+// watchRemote starts polling remote files unless running offline
+func watchRemote(ctx context.Context, rw *watcher.RemoteWatcher, urls []string, offline bool, events chan<- watcher.Event) {
+	if offline {
+		return
+	}
+	if err := rw.Watch(ctx, urls, events); err != nil {
+		log.Printf("Error watching remote files: %v", err)
+	}
+}
+
+// updateWatcher adds files that are in next but not in prev to the watcher and removes those that are gone
+func updateWatcher(w watcher.Watcher, prev, next []string) {
+	for _, file := range difference(prev, next) {
+		if err := w.Remove(file); err != nil {
+			log.Printf("Error unwatching %s: %v", file, err)
+		}
+	}
+	for _, file := range difference(next, prev) {
+		if err := w.Add(file); err != nil {
+			log.Printf("Error watching %s: %v", file, err)
+		}
+	}
+}
+
+// difference returns the files in a that are not in b
+func difference(a, b []string) []string {
+	present := make(map[string]bool, len(b))
+	for _, file := range b {
+		present[file] = true
+	}
+	var missing []string
+	for _, file := range a {
+		if !present[file] {
+			missing = append(missing, file)
+		}
+	}
+	return missing
+}
+
+// localWatchList returns the local files and directories to watch, including the configuration file when one is used
+func localWatchList(targets []*target, configPath string) []string {
+	local, _ := watchedFiles(targets)
+	if configPath != "" {
+		local = append(local, configPath)
+	}
+	return local
+}
@@ -0,0 +1,311 @@
+//go:build small
+
+package wampa
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toms74209200/wampa/pkg/config"
+)
+
+// writeConfig writes the content of a configuration file for a test
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestApplyCLIOptions tests overriding configuration file settings with command line options
+func TestApplyCLIOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		opts config.CLIOptions
+		want config.Config
+	}{
+		{
+			name: "オプションなしでは設定ファイルの値を使う",
+			cfg:  config.Config{InputFiles: []string{"a.md"}, OutputFile: "out.md", OutputFormat: "xml"},
+			opts: config.CLIOptions{InputFiles: []string{}},
+			want: config.Config{InputFiles: []string{"a.md"}, OutputFile: "out.md", OutputFormat: "xml"},
+		},
+		{
+			name: "-iは入力ファイルを置き換える",
+			cfg:  config.Config{InputFiles: []string{"a.md"}, OutputFile: "out.md"},
+			opts: config.CLIOptions{InputFiles: []string{"b.md", "c.md"}},
+			want: config.Config{InputFiles: []string{"b.md", "c.md"}, OutputFile: "out.md"},
+		},
+		{
+			name: "-oは設定された出力をすべて置き換える",
+			cfg: config.Config{
+				InputFiles: []string{"a.md"},
+				OutputFile: "out.md",
+				Outputs:    []config.Output{{Path: "other.md"}},
+			},
+			opts: config.CLIOptions{OutputFile: "cli.md"},
+			want: config.Config{InputFiles: []string{"a.md"}, OutputFile: "cli.md"},
+		},
+		{
+			name: "標準入力と出力形式を上書きする",
+			cfg:  config.Config{InputFiles: []string{"a.md"}, OutputFile: "out.md", OutputFormat: "xml"},
+			opts: config.CLIOptions{Stdin: true, OutputFormat: "json"},
+			want: config.Config{InputFiles: []string{"a.md"}, OutputFile: "out.md", OutputFormat: "json", Stdin: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			applyCLIOptions(&cfg, &tt.opts)
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("applyCLIOptions() = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadConfigFile tests loading a configuration file with command line options applied
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    config.CLIOptions
+		want    *config.Config
+		wantErr bool
+	}{
+		{
+			name:    "有効な設定ファイル",
+			content: `{"input_files": ["a.md"], "output_file": "out.md"}`,
+			want:    &config.Config{InputFiles: []string{"a.md"}, OutputFile: "out.md"},
+		},
+		{
+			name:    "コマンドラインオプションで上書きする",
+			content: `{"input_files": ["a.md"], "output_file": "out.md"}`,
+			opts:    config.CLIOptions{InputFiles: []string{"b.md"}, OutputFile: "cli.md"},
+			want:    &config.Config{InputFiles: []string{"b.md"}, OutputFile: "cli.md"},
+		},
+		{
+			name:    "JSONの構文エラー",
+			content: `{"input_files": ["a.md"],`,
+			wantErr: true,
+		},
+		{
+			name:    "検証エラー",
+			content: `{"input_files": ["a.md"], "output_file": "out.md", "output_format": "yaml"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wampa.json")
+			writeConfig(t, path, tt.content)
+
+			got, err := loadConfigFile(path, &tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfigFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if got != nil {
+					t.Errorf("loadConfigFile() = %+v, want nil", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadConfigFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("存在しないファイル", func(t *testing.T) {
+		if _, err := loadConfigFile(filepath.Join(t.TempDir(), "wampa.json"), &config.CLIOptions{}); err == nil {
+			t.Error("loadConfigFile() error = nil, want error")
+		}
+	})
+}
+
+// TestLoadConfigFile_Reload tests loading an edited configuration file as a reload does
+func TestLoadConfigFile_Reload(t *testing.T) {
+	tests := []struct {
+		name    string
+		edited  string
+		opts    config.CLIOptions
+		want    *config.Config
+		wantErr bool
+	}{
+		{
+			name:   "編集後の設定を読み込む",
+			edited: `{"input_files": ["b.md"], "output_file": "new.md"}`,
+			want:   &config.Config{InputFiles: []string{"b.md"}, OutputFile: "new.md"},
+		},
+		{
+			name:    "無効な設定では以前の設定を保つ",
+			edited:  `{"input_files": [""], "output_file": "new.md"}`,
+			wantErr: true,
+		},
+		{
+			name:   "再読み込み後も-iと-oが優先される",
+			edited: `{"input_files": ["b.md"], "output_file": "new.md", "outputs": [{"path": "other.md"}]}`,
+			opts:   config.CLIOptions{InputFiles: []string{"cli.md"}, OutputFile: "cli-out.md"},
+			want:   &config.Config{InputFiles: []string{"cli.md"}, OutputFile: "cli-out.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wampa.json")
+			writeConfig(t, path, `{"input_files": ["a.md"], "output_file": "out.md"}`)
+			cfg, err := loadConfigFile(path, &tt.opts)
+			if err != nil {
+				t.Fatalf("loadConfigFile() error = %v", err)
+			}
+			initial := *cfg
+
+			writeConfig(t, path, tt.edited)
+			newCfg, err := loadConfigFile(path, &tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfigFile() after editing error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(*cfg, initial) {
+				t.Errorf("previous configuration = %+v, want %+v", *cfg, initial)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(newCfg, tt.want) {
+				t.Errorf("loadConfigFile() after editing = %+v, want %+v", newCfg, tt.want)
+			}
+		})
+	}
+}
+
+// TestRestartOnlyChanged tests detecting changes to settings that are applied only at startup
+func TestRestartOnlyChanged(t *testing.T) {
+	retries := 3
+	tests := []struct {
+		name string
+		prev config.Config
+		next config.Config
+		want bool
+	}{
+		{
+			name: "入出力の変更は再読み込みで反映される",
+			prev: config.Config{InputFiles: []string{"a.md"}, OutputFile: "out.md", RemotePollInterval: "5m"},
+			next: config.Config{InputFiles: []string{"b.md"}, OutputFile: "new.md", RemotePollInterval: "1m"},
+			want: false,
+		},
+		{
+			name: "HTTP設定の変更",
+			prev: config.Config{},
+			next: config.Config{HTTP: config.HTTPOptions{Retries: &retries}},
+			want: true,
+		},
+		{
+			name: "変更検出方式の変更",
+			prev: config.Config{},
+			next: config.Config{ChangeDetection: "content"},
+			want: true,
+		},
+		{
+			name: "ホストのヘッダーの変更",
+			prev: config.Config{},
+			next: config.Config{Hosts: map[string]config.HostOptions{
+				"example.com": {Headers: map[string]string{"Authorization": "Bearer ${TOKEN}"}},
+			}},
+			want: true,
+		},
+		{
+			name: "URLのヘッダーの変更",
+			prev: config.Config{},
+			next: config.Config{Remotes: map[string]config.RemoteOptions{
+				"https://example.com/a.md": {Headers: map[string]string{"X-API-Key": "${KEY}"}},
+			}},
+			want: true,
+		},
+		{
+			name: "ポーリング間隔の変更は再読み込みで反映される",
+			prev: config.Config{},
+			next: config.Config{Remotes: map[string]config.RemoteOptions{
+				"https://example.com/a.md": {PollInterval: "1m"},
+			}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := restartOnlyChanged(&tt.prev, &tt.next); got != tt.want {
+				t.Errorf("restartOnlyChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDifference tests listing the files that are only in the first list
+func TestDifference(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want []string
+	}{
+		{name: "同じリスト", a: []string{"a.md", "b.md"}, b: []string{"b.md", "a.md"}, want: nil},
+		{name: "追加されたファイル", a: []string{"a.md", "b.md", "c.md"}, b: []string{"b.md"}, want: []string{"a.md", "c.md"}},
+		{name: "空のリストとの差", a: []string{"a.md"}, b: nil, want: []string{"a.md"}},
+		{name: "空のリスト", a: nil, b: []string{"a.md"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := difference(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("difference() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLocalWatchList tests listing the local files and directories to watch
+func TestLocalWatchList(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    []*target
+		configPath string
+		want       []string
+	}{
+		{
+			name: "リモートファイルを除く",
+			targets: []*target{
+				{inputs: []string{"a.md", "https://example.com/b.md"}},
+			},
+			want: []string{"a.md"},
+		},
+		{
+			name: "重複を除きディレクトリを含める",
+			targets: []*target{
+				{inputs: []string{"a.md", "docs/b.md"}, dirs: []string{"docs"}},
+				{inputs: []string{"a.md", "docs/c.md"}, dirs: []string{"docs"}},
+			},
+			want: []string{"a.md", "docs/b.md", "docs/c.md", "docs"},
+		},
+		{
+			name: "設定ファイルを含める",
+			targets: []*target{
+				{inputs: []string{"a.md"}},
+			},
+			configPath: "wampa.json",
+			want:       []string{"a.md", "wampa.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := localWatchList(tt.targets, tt.configPath); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("localWatchList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
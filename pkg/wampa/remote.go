@@ -0,0 +1,168 @@
+package wampa
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/toms74209200/wampa/pkg/cache"
+	"github.com/toms74209200/wampa/pkg/lock"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+// remoteFetcher fetches remote input files through the on-disk cache
+type remoteFetcher struct {
+	client watcher.HTTPClient
+	cache  *cache.Cache
+	// offline serves remote files only from the cache
+	offline bool
+	// live requires every file to be confirmed by the server, without falling back to the cache
+	live bool
+	// maxAge returns the age after which a cached entry is reported as stale
+	maxAge func(url string) time.Duration
+}
+
+// fetchResult is the outcome of fetching a single remote file
+type fetchResult struct {
+	content []byte
+	state   watcher.RemoteFileState
+	// confirmed reports that the server sent or confirmed the content, rather than it being served from the cache
+	confirmed bool
+	err       error
+}
+
+// fetch returns the content of a remote file.
+// A cached copy is revalidated with a conditional request and is used when the server cannot be reached,
+// unless live content is required. In offline mode only the cached copy is used.
+// Fetched content is not cached here, so that content refused by the lockfile never enters the cache.
+func (f *remoteFetcher) fetch(ctx context.Context, url string) fetchResult {
+	cached, ok, err := f.cache.Get(url)
+	if err != nil {
+		log.Printf("Error reading cache for %s: %v", url, err)
+	}
+	// The cached copy replaces content that cannot be fetched
+	fallback := ok && !f.live
+
+	if f.offline {
+		if f.live {
+			return fetchResult{state: watcher.RemoteFileState{URL: url}, err: fmt.Errorf("%s cannot be fetched in offline mode", url)}
+		}
+		if !ok {
+			return fetchResult{state: watcher.RemoteFileState{URL: url}, err: fmt.Errorf("%s is not cached; run once without --offline to cache it", url)}
+		}
+		f.logCached(url, cached, "offline")
+		return fetchResult{content: cached.Content, state: cached.State}
+	}
+
+	state := watcher.RemoteFileState{URL: url}
+	if ok {
+		state = cached.State
+	}
+	req, err := watcher.CreateConditionalRemoteFileRequest(ctx, state, nil)
+	if err != nil {
+		return fetchResult{state: state, err: err}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		// A cancelled fetch is not replaced by the cached copy
+		if fallback && ctx.Err() == nil {
+			log.Printf("Error fetching %s: %v", url, err)
+			f.logCached(url, cached, "server unreachable")
+			return fetchResult{content: cached.Content, state: cached.State}
+		}
+		return fetchResult{state: state, err: fmt.Errorf("fetching %s: %w", url, err)}
+	}
+	defer resp.Body.Close()
+
+	// The cached copy is still current
+	if resp.StatusCode == http.StatusNotModified && ok {
+		return fetchResult{content: cached.Content, state: cached.State, confirmed: true}
+	}
+
+	data, newState, err := watcher.ProcessRemoteFileResponse(resp, url, maxFileSize)
+	if err != nil {
+		if fallback {
+			log.Printf("Error processing response from %s: %v", url, err)
+			f.logCached(url, cached, "server error")
+			return fetchResult{content: cached.Content, state: cached.State}
+		}
+		return fetchResult{state: newState, err: err}
+	}
+	return fetchResult{content: data, state: newState, confirmed: true}
+}
+
+// fetchAll fetches remote files with at most limit requests in flight.
+// Results are returned in the order of urls. Cancelling ctx aborts requests in flight
+// and fails the ones that have not started.
+func (f *remoteFetcher) fetchAll(ctx context.Context, urls []string, limit int) []fetchResult {
+	if limit < 1 {
+		limit = 1
+	}
+	results := make([]fetchResult, len(urls))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = fetchResult{state: watcher.RemoteFileState{URL: url}, err: ctx.Err()}
+				return
+			}
+			results[i] = f.fetch(ctx, url)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// fetchRemotes fetches remote inputs in parallel and verifies their content against the lockfile.
+// Accepted content is recorded in the remote watcher, and in the cache when it was confirmed by the server.
+// It returns the URLs that could not be fetched or verified, and separately those whose content was refused by the lockfile.
+func fetchRemotes(ctx context.Context, f *remoteFetcher, rw *watcher.RemoteWatcher, lk *lock.Lock, urls []string, limit int, updateLock bool) (failed, refused []string) {
+	for i, result := range f.fetchAll(ctx, urls, limit) {
+		url := urls[i]
+		if result.err != nil {
+			log.Printf("Error fetching %s: %v", url, result.err)
+			failed = append(failed, url)
+			continue
+		}
+
+		// Verify the content against the lockfile
+		if err := applyLock(lk, result.content, result.state, updateLock); err != nil {
+			log.Printf("Error verifying %s: %v", url, err)
+			failed = append(failed, url)
+			refused = append(refused, url)
+			continue
+		}
+		rw.SetState(result.content, result.state)
+		if result.confirmed {
+			f.store(result.content, result.state)
+		}
+	}
+	return failed, refused
+}
+
+// store records fetched content in the cache
+func (f *remoteFetcher) store(content []byte, state watcher.RemoteFileState) {
+	if err := f.cache.Put(cache.Entry{State: state, Content: content, FetchedAt: time.Now()}); err != nil {
+		log.Printf("Error writing cache for %s: %v", state.URL, err)
+	}
+}
+
+// logCached reports the use of a cached copy, marking it as stale when it is older than its maximum age
+func (f *remoteFetcher) logCached(url string, entry cache.Entry, reason string) {
+	age := entry.Age(time.Now()).Round(time.Second)
+	if age > f.maxAge(url) {
+		log.Printf("Using STALE cached copy of %s (%s), fetched %s ago at %s", url, reason, age, entry.FetchedAt.Format(time.RFC3339))
+		return
+	}
+	log.Printf("Using cached copy of %s (%s), fetched %s ago", url, reason, age)
+}
@@ -0,0 +1,270 @@
+//go:build small
+
+package wampa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toms74209200/wampa/pkg/cache"
+	"github.com/toms74209200/wampa/pkg/lock"
+	"github.com/toms74209200/wampa/pkg/watcher"
+)
+
+// newTestFetcher creates a remoteFetcher with an empty cache in a temporary directory
+func newTestFetcher(t *testing.T) *remoteFetcher {
+	t.Helper()
+	return &remoteFetcher{
+		client: http.DefaultClient,
+		cache:  cache.New(t.TempDir()),
+		maxAge: func(string) time.Duration { return time.Minute },
+	}
+}
+
+// newContentServer starts a server that serves content with an ETag and answers matching conditional requests with 304
+func newContentServer(t *testing.T, content, etag string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestRemoteFetcher_Fetch tests fetching through the cache, including the fallback to cached copies
+func TestRemoteFetcher_Fetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  string
+		cached  bool
+		offline bool
+		live    bool
+		want    string
+		// wantConfirmed reports that the content must come from the server rather than the cache
+		wantConfirmed bool
+		wantErr       bool
+	}{
+		{name: "新しいファイルを取得する", server: "ok", want: "remote v2", wantConfirmed: true},
+		{name: "キャッシュを条件付きリクエストで再検証する", server: "not modified", cached: true, want: "cached v1", wantConfirmed: true},
+		{name: "更新されたファイルを取得する", server: "ok", cached: true, want: "remote v2", wantConfirmed: true},
+		{name: "サーバーに接続できなければキャッシュを使う", server: "down", cached: true, want: "cached v1"},
+		{name: "サーバーエラーではキャッシュを使う", server: "error", cached: true, want: "cached v1"},
+		{name: "キャッシュがなく接続できなければ失敗する", server: "down", wantErr: true},
+		{name: "liveではキャッシュに頼らず失敗する", server: "down", cached: true, live: true, wantErr: true},
+		{name: "liveではサーバーエラーで失敗する", server: "error", cached: true, live: true, wantErr: true},
+		{name: "オフラインではキャッシュを使う", server: "down", cached: true, offline: true, want: "cached v1"},
+		{name: "オフラインでキャッシュがなければ失敗する", server: "down", offline: true, wantErr: true},
+		{name: "オフラインではliveの取得は失敗する", server: "ok", cached: true, offline: true, live: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case tt.server == "error":
+					w.WriteHeader(http.StatusInternalServerError)
+				case tt.server == "not modified" && r.Header.Get("If-None-Match") == `"v1"`:
+					w.WriteHeader(http.StatusNotModified)
+				default:
+					w.Header().Set("ETag", `"v2"`)
+					w.Write([]byte("remote v2"))
+				}
+			}))
+			defer server.Close()
+			url := server.URL + "/rules.md"
+			if tt.server == "down" {
+				server.Close()
+			}
+
+			f := newTestFetcher(t)
+			f.offline, f.live = tt.offline, tt.live
+			if tt.cached {
+				entry := cache.Entry{State: watcher.RemoteFileState{URL: url, ETag: `"v1"`}, Content: []byte("cached v1"), FetchedAt: time.Now()}
+				if err := f.cache.Put(entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result := f.fetch(context.Background(), url)
+			if (result.err != nil) != tt.wantErr {
+				t.Fatalf("fetch() error = %v, wantErr %v", result.err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(result.content) != tt.want {
+				t.Errorf("fetch() content = %q, want %q", result.content, tt.want)
+			}
+			if result.confirmed != tt.wantConfirmed {
+				t.Errorf("fetch() confirmed = %v, want %v", result.confirmed, tt.wantConfirmed)
+			}
+		})
+	}
+}
+
+// TestFetchRemotes_Cache tests that only content accepted by the lockfile is cached
+func TestFetchRemotes_Cache(t *testing.T) {
+	tests := []struct {
+		name        string
+		locked      string
+		updateLock  bool
+		wantRefused bool
+		wantCached  bool
+	}{
+		{name: "未登録のURLは固定してキャッシュする", wantCached: true},
+		{name: "ロックと一致する内容をキャッシュする", locked: "remote", wantCached: true},
+		{name: "ロックと異なる内容はキャッシュしない", locked: "old", wantRefused: true},
+		{name: "--update-lockでは新しい内容をキャッシュする", locked: "old", updateLock: true, wantCached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newContentServer(t, "remote", `"v1"`)
+			url := server.URL + "/rules.md"
+
+			lk := lock.New()
+			if tt.locked != "" {
+				lk.Set(lock.NewEntry(watcher.RemoteFileState{URL: url}, []byte(tt.locked), time.Now()))
+			}
+			rw, err := watcher.NewRemoteWatcher(http.DefaultClient, maxFileSize, func(string) time.Duration { return time.Minute })
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rw.Close()
+
+			f := newTestFetcher(t)
+			failed, refused := fetchRemotes(context.Background(), f, rw, lk, []string{url}, 1, tt.updateLock)
+			if got := len(refused) > 0; got != tt.wantRefused {
+				t.Errorf("fetchRemotes() refused = %v, want refused %v", refused, tt.wantRefused)
+			}
+			if got := len(failed) > 0; got != tt.wantRefused {
+				t.Errorf("fetchRemotes() failed = %v, want failed %v", failed, tt.wantRefused)
+			}
+
+			entry, cached, err := f.cache.Get(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cached != tt.wantCached {
+				t.Fatalf("cached = %v, want %v", cached, tt.wantCached)
+			}
+			if cached && string(entry.Content) != "remote" {
+				t.Errorf("cached content = %q, want %q", entry.Content, "remote")
+			}
+			if content, ok := rw.Content(url); ok == tt.wantRefused || (ok && string(content) != "remote") {
+				t.Errorf("remote watcher content = %q, %v", content, ok)
+			}
+		})
+	}
+}
+
+// TestRemoteFetcher_FetchAll tests the order of results and the limit of requests in flight
+func TestRemoteFetcher_FetchAll(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		urls  int
+	}{
+		{name: "順番に取得する", limit: 1, urls: 4},
+		{name: "並列数を制限する", limit: 2, urls: 6},
+		{name: "URL数より大きい上限", limit: 8, urls: 3},
+		{name: "0は1として扱う", limit: 0, urls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			inFlight, peak := 0, 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				inFlight++
+				peak = max(peak, inFlight)
+				mu.Unlock()
+				// Later URLs answer sooner, so that results finish out of order
+				var i int
+				fmt.Sscanf(r.URL.Path, "/%d.md", &i)
+				time.Sleep(time.Duration(50+10*(tt.urls-i)) * time.Millisecond)
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				w.Write([]byte(r.URL.Path))
+			}))
+			defer server.Close()
+
+			urls := make([]string, tt.urls)
+			for i := range urls {
+				urls[i] = fmt.Sprintf("%s/%d.md", server.URL, i)
+			}
+			results := newTestFetcher(t).fetchAll(context.Background(), urls, tt.limit)
+
+			if len(results) != len(urls) {
+				t.Fatalf("fetchAll() returned %d results, want %d", len(results), len(urls))
+			}
+			for i, result := range results {
+				if result.err != nil {
+					t.Errorf("fetchAll()[%d] error = %v", i, result.err)
+					continue
+				}
+				if want := urls[i][len(server.URL):]; string(result.content) != want {
+					t.Errorf("fetchAll()[%d] content = %q, want %q", i, result.content, want)
+				}
+			}
+			if want := min(max(tt.limit, 1), tt.urls); peak != want {
+				t.Errorf("peak requests in flight = %d, want %d", peak, want)
+			}
+		})
+	}
+}
+
+// TestRemoteFetcher_FetchAll_Cancel tests that cancelling the context aborts requests in flight and those not started
+func TestRemoteFetcher_FetchAll_Cancel(t *testing.T) {
+	started := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		// Block until the request is cancelled
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	urls := []string{server.URL + "/a.md", server.URL + "/b.md", server.URL + "/c.md"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan []fetchResult)
+	go func() {
+		done <- newTestFetcher(t).fetchAll(ctx, urls, 2)
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for requests")
+		}
+	}
+	cancel()
+
+	select {
+	case results := <-done:
+		for i, result := range results {
+			if !errors.Is(result.err, context.Canceled) {
+				t.Errorf("fetchAll()[%d] error = %v, want %v", i, result.err, context.Canceled)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("fetchAll() did not return after cancelling")
+	}
+	if n := len(started); n != 0 {
+		t.Errorf("%d requests started after cancelling", n)
+	}
+}
@@ -3,14 +3,19 @@ package wampa
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
+	"path/filepath"
 
+	"github.com/toms74209200/wampa/pkg/auth"
+	"github.com/toms74209200/wampa/pkg/cache"
 	"github.com/toms74209200/wampa/pkg/config"
 	"github.com/toms74209200/wampa/pkg/formatter"
+	"github.com/toms74209200/wampa/pkg/httpclient"
+	"github.com/toms74209200/wampa/pkg/lock"
 	"github.com/toms74209200/wampa/pkg/watcher"
 )
 
@@ -25,9 +30,6 @@ const maxFileSize = 100 * MB
 
 // Run executes the main application logic
 func Run(ctx context.Context, args []string) error {
-	// Cache for remote file contents
-	remoteContents := make(map[string]string)
-
 	// Check for help flag first
 	if config.CheckHelpFlag(args) {
 		fmt.Println(config.HelpMessage)
@@ -43,10 +45,13 @@ func Run(ctx context.Context, args []string) error {
 	}
 
 	var cfg *config.Config
+	// Directory containing .wampaignore and the base of relative section paths;
+	// the config file's directory when one is loaded
+	projectRoot := "."
 
 	// Check if config file exists and load it
 	configFile := cliOpts.ConfigFile
-	if configFile == "wampa.json" && len(args) == 0 {
+	if configFile == "wampa.json" && (len(args) == 0 || (cliOpts.Command != "" && len(args) == 1)) {
 		// When no arguments are provided and using default config
 		_, err := os.Stat(configFile)
 		if os.IsNotExist(err) {
@@ -56,15 +61,17 @@ func Run(ctx context.Context, args []string) error {
 		}
 	}
 
+	// Path of the loaded configuration file, which is reloaded when it changes while watching
+	var configPath string
 	if cliOpts.ConfigFile != "" {
-		data, err := os.ReadFile(cliOpts.ConfigFile)
-		if err == nil {
-			// Config file found and loaded successfully
-			fileCfg, err := config.Parse(data)
+		if _, err := os.Stat(cliOpts.ConfigFile); err == nil {
+			// Config file found; command line options override its settings
+			cfg, err = loadConfigFile(cliOpts.ConfigFile, cliOpts)
 			if err != nil {
-				return fmt.Errorf("failed to parse config file: %w", err)
+				return err
 			}
-			cfg = fileCfg
+			configPath = cliOpts.ConfigFile
+			projectRoot = filepath.Dir(cliOpts.ConfigFile)
 		} else if !os.IsNotExist(err) {
 			// Config file exists but there was an error loading it
 			return fmt.Errorf("failed to load config file: %w", err)
@@ -80,144 +87,456 @@ func Run(ctx context.Context, args []string) error {
 			fmt.Println(config.HelpMessage)
 			return fmt.Errorf("invalid configuration: %w", err)
 		}
-	} else {
-		// Override file config with CLI options if provided
-		if len(cliOpts.InputFiles) > 0 {
-			cfg.InputFiles = cliOpts.InputFiles
+
+		// Validate final config
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
 		}
-		if cliOpts.OutputFile != "" {
-			cfg.OutputFile = cliOpts.OutputFile
+	}
+
+	// Authenticate remote requests and keep credentials out of every log line
+	netrc, err := auth.LoadNetrc(os.Getenv)
+	if err != nil {
+		log.Printf("Ignoring .netrc: %v", err)
+	}
+	resolver, err := auth.NewResolver(cfg.URLHeaders(), cfg.HostHeaders(), auth.DefaultTokenSources, netrc, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resolve remote headers: %v\n\n", err)
+		return fmt.Errorf("failed to resolve remote headers: %w", err)
+	}
+	logOutput := log.Writer()
+	log.SetOutput(auth.NewRedactor(logOutput, resolver.Secrets()))
+	defer log.SetOutput(logOutput)
+	stderr := auth.NewRedactor(os.Stderr, resolver.Secrets())
+	clientOptions := cfg.ClientOptions()
+	clientOptions.CheckRedirect = resolver.CheckRedirect
+	httpClient, err := httpclient.New(clientOptions)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to create HTTP client: %v\n\n", err)
+		return fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	client := resolver.Client(httpClient)
+
+	// Read standard input once; it cannot change while watching
+	var stdin *formatter.Section
+	if cfg.Stdin {
+		content, err := readStdin(os.Stdin, maxFileSize)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return fmt.Errorf("failed to read standard input: %w", err)
 		}
+		section := stdinSection(content)
+		stdin = &section
 	}
 
-	// Validate final config
-	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+	// Create a target for every output file and resolve its inputs
+	targets, ignore, generated, err := prepareTargets(cfg, projectRoot)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to set up output files: %v\n\n", err)
+		return fmt.Errorf("failed to set up output files: %w", err)
 	}
+	outputs := outputFiles(targets)
 
-	// Create formatter
-	formatter := formatter.NewDefaultFormatter()
+	lockPath := filepath.Join(projectRoot, lock.FileName)
+	lk, err := lock.Load(lockPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load lockfile: %v\n\n", err)
+		return fmt.Errorf("failed to load lockfile: %w", err)
+	}
+	updateLock := cliOpts.UpdateLock || cliOpts.Command == config.CommandUpdate
 
-	// Create and initialize watcher
-	w, err := watcher.NewLocalWatcher()
+	// Create and initialize remote watcher
+	rw, err := watcher.NewRemoteWatcher(client, maxFileSize, cfg.PollInterval)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create watcher: %v\n\n", err)
-		return fmt.Errorf("failed to create watcher: %w", err)
+		fmt.Fprintf(stderr, "Failed to create remote watcher: %v\n\n", err)
+		return fmt.Errorf("failed to create remote watcher: %w", err)
+	}
+	defer func() {
+		rw.Close()
+	}()
+
+	// Remote files are fetched through the on-disk cache
+	fetcher := &remoteFetcher{
+		client:  client,
+		offline: cliOpts.Offline,
+		// Updating the lockfile pins what the servers serve now, never an older cached copy
+		live:   cliOpts.Command == config.CommandUpdate,
+		maxAge: cfg.PollInterval,
+	}
+	if dir, err := cache.DefaultDir(); err != nil {
+		log.Printf("Caching of remote files is disabled: %v", err)
+	} else {
+		fetcher.cache = cache.New(dir)
+	}
+	if cliOpts.Offline {
+		log.Printf("Offline mode: remote files are served from the cache and not polled")
 	}
-	defer w.Close()
 
 	// Create channel for file change events
 	events := make(chan watcher.Event)
 
-	// Start watching files
-	log.Printf("Watching files: %v", cfg.InputFiles)
-	log.Printf("Output file: %s", cfg.OutputFile)
+	// Start watching the inputs of all outputs with a single watcher,
+	// including directories that may receive new matches and the configuration file
+	localFiles := localWatchList(targets, configPath)
+	_, remoteFiles := watchedFiles(targets)
+	// One-shot builds and checks read the inputs once without watching
+	watch := !cliOpts.Once && cliOpts.Command == ""
+	if !watch {
+		log.Printf("Input files: %v", allInputs(targets))
+	} else {
+		log.Printf("Watching files: %v", allInputs(targets))
+	}
+	for _, output := range outputs {
+		log.Printf("Output file: %s", output)
+	}
 
-	go func() {
-		if err := w.Watch(ctx, cfg.InputFiles, events); err != nil {
-			log.Printf("Error watching files: %v", err)
+	var w watcher.Watcher
+	if watch {
+		// Create and initialize watcher
+		w, err = watcher.NewWatcher(cfg.WatcherOptions())
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to create watcher: %v\n\n", err)
+			return fmt.Errorf("failed to create watcher: %w", err)
 		}
-	}()
+		defer func() {
+			w.Close()
+		}()
+
+		watchLocal(ctx, w, localFiles, events)
+	}
+
+	// Inputs that could not be read or fetched, and outputs that could not be written
+	var failedInputs, failedOutputs []string
+	// Remote inputs whose content was refused by the lockfile
+	var refusedInputs []string
+
+	// Sections of local inputs, kept until the watcher reports a change to their file
+	contents := newLocalContents()
 
 	// Generate initial output
 	{
-		// Read all input files
-		contents := make(map[string]string)
-		for _, file := range cfg.InputFiles {
+		// Create the sections of all input files
+		sections := make(map[string]formatter.Section)
+		// Remote files are fetched in parallel and kept by the remote watcher for change events
+		failedInputs, refusedInputs = fetchRemotes(ctx, fetcher, rw, lk, remoteFiles, cfg.FetchConcurrency(), updateLock)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for _, file := range allInputs(targets) {
 			// Check if the file is a remote URL
-			if u, err := url.Parse(file); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
-				// Create HTTP request
-				req, err := watcher.CreateRemoteFileRequest(ctx, file, nil)
-				if err != nil {
-					log.Printf("Error creating request for %s: %v", file, err)
-					break
+			if isRemote(file) {
+				if section, ok := remoteSection(file, rw); ok {
+					sections[file] = section
 				}
+				continue
+			}
 
-				// Execute request
-				resp, err := http.DefaultClient.Do(req)
-				if err != nil {
-					log.Printf("Error fetching %s: %v", file, err)
-					break
-				}
-				defer resp.Body.Close()
+			// Handle local file
+			section, err := contents.section(file, cfg.PlaceholderForMissing())
+			if err != nil {
+				log.Printf("Error generating initial output - failed to read file %s: %v", file, err)
+				failedInputs = append(failedInputs, file)
+				continue
+			}
+			sections[file] = section
+		}
 
-				// Process response
-				data, _, err := watcher.ProcessRemoteFileResponse(resp, file, maxFileSize)
-				if err != nil {
-					log.Printf("Error processing response from %s: %v", file, err)
-					break
-				}
-				contents[file] = string(data)
-				// Cache remote content
-				remoteContents[file] = string(data)
-			} else {
-				// Handle local file
-				data, err := os.ReadFile(file)
-				if err != nil {
-					log.Printf("Error generating initial output - failed to read file %s: %v", file, err)
-					break
-				}
-				contents[file] = string(data)
+		if cliOpts.Command == config.CommandUpdate {
+			if len(failedInputs) > 0 {
+				fmt.Fprintf(stderr, "Error: failed to read input files: %v\n", failedInputs)
+				return fmt.Errorf("failed to read input files: %v", failedInputs)
+			}
+			// Drop URLs that are no longer inputs
+			lk.Prune(remoteFiles)
+			if err := lk.Save(lockPath); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				return err
+			}
+			log.Printf("Lockfile is up to date: %s", lockPath)
+			return nil
+		}
+
+		// A check never writes files, including newly pinned lockfile entries
+		if cliOpts.Command != config.CommandCheck {
+			if err := lk.Save(lockPath); err != nil {
+				log.Printf("Error saving lockfile: %v", err)
+			}
+		}
+
+		if cliOpts.Command == config.CommandCheck {
+			if len(failedInputs) > 0 {
+				fmt.Fprintf(stderr, "Error: failed to read input files: %v\n", failedInputs)
+				return fmt.Errorf("failed to read input files: %v", failedInputs)
+			}
+			stale, err := checkOutputs(os.Stdout, targets, sections, stdin, projectRoot)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				return err
+			}
+			if len(stale) > 0 {
+				fmt.Fprintf(stderr, "Output files are out of date: %v\n", stale)
+				return fmt.Errorf("output files are out of date: %v", stale)
+			}
+			log.Printf("Output files are up to date")
+			return nil
+		}
+
+		// A one-shot build leaves the outputs untouched rather than writing them without some inputs
+		if cliOpts.Once && len(failedInputs) > 0 {
+			fmt.Fprintf(stderr, "Error: failed to read input files: %v\n", failedInputs)
+			return fmt.Errorf("failed to read input files: %v", failedInputs)
+		}
+
+		// Format contents and write every output file,
+		// leaving outputs that use refused remote content untouched
+		for _, t := range targets {
+			if refused := t.usesAny(refusedInputs); refused != "" {
+				log.Printf("Skipping output file %s: content of %s was refused by %s", t.output, refused, lock.FileName)
+				failedOutputs = append(failedOutputs, t.output)
+				continue
 			}
+			if err := t.build(sections, stdin, projectRoot); err != nil {
+				log.Printf("Error generating initial output - %v", err)
+				failedOutputs = append(failedOutputs, t.output)
+			}
+		}
+	}
+
+	if cliOpts.Once {
+		if len(failedOutputs) > 0 {
+			fmt.Fprintf(stderr, "Error: failed to write output files: %v\n", failedOutputs)
+			return fmt.Errorf("failed to write output files: %v", failedOutputs)
 		}
+		return nil
+	}
+
+	// Start polling remote files once their initial state is known
+	watchRemote(ctx, rw, remoteFiles, cliOpts.Offline, events)
+
+	// Process bursts of events as batches, so that each output is rebuilt once per burst
+	batches := make(chan []watcher.Event)
+	debouncer := watcher.NewDebouncer(cfg.DebounceWindow())
+	go debouncer.Run(ctx, events, batches)
 
-		// Format contents
-		output, err := formatter.Format(cfg.InputFiles, contents)
+	// reload applies the edited configuration file and returns the targets to rebuild.
+	// It reports false when the previous configuration is kept.
+	reload := func() ([]*target, bool) {
+		log.Printf("Configuration file changed: %s", configPath)
+
+		// Keep running with the current configuration when the new one cannot be used
+		newCfg, err := loadConfigFile(configPath, cliOpts)
+		if err != nil {
+			log.Printf("Keeping the previous configuration: %v", err)
+			return nil, false
+		}
+		newTargets, newIgnore, newGenerated, err := prepareTargets(newCfg, projectRoot)
+		if err != nil {
+			log.Printf("Keeping the previous configuration: %v", err)
+			return nil, false
+		}
+		if restartOnlyChanged(cfg, newCfg) {
+			log.Printf("Changes to http, hosts, header and change_detection settings take effect after restarting wampa")
+		}
+		_, newRemoteFiles := watchedFiles(newTargets)
+		newRW, err := restartRemoteWatcher(rw, client, newCfg, newRemoteFiles)
 		if err != nil {
-			log.Printf("Error generating initial output - failed to format content: %v", err)
-		} else {
-			// Write to output file
-			if err := os.WriteFile(cfg.OutputFile, []byte(output), 0644); err != nil {
-				log.Printf("Error generating initial output - failed to write to output file: %v", err)
-			} else {
-				log.Printf("Output file updated: %s", cfg.OutputFile)
+			log.Printf("Keeping the previous configuration: %v", err)
+			return nil, false
+		}
+
+		cfg, targets, ignore, generated, rw = newCfg, newTargets, newIgnore, newGenerated, newRW
+		contents.retain(allInputs(targets))
+		fetcher.maxAge = cfg.PollInterval
+		debouncer.SetWindow(cfg.DebounceWindow())
+		outputs = outputFiles(targets)
+		log.Printf("Reloaded configuration: %s", configPath)
+		log.Printf("Watching files: %v", allInputs(targets))
+		for _, output := range outputs {
+			log.Printf("Output file: %s", output)
+		}
+
+		// Fetch remote files that were added, then poll the new set.
+		// The new remote watcher only keeps the contents of remote files that are still inputs.
+		var added []string
+		for _, url := range newRemoteFiles {
+			if _, ok := rw.Content(url); !ok {
+				added = append(added, url)
+			}
+		}
+		remoteFiles = newRemoteFiles
+		_, refused := fetchRemotes(ctx, fetcher, rw, lk, added, cfg.FetchConcurrency(), updateLock)
+		if err := lk.Save(lockPath); err != nil {
+			log.Printf("Error saving lockfile: %v", err)
+		}
+		watchRemote(ctx, rw, remoteFiles, cliOpts.Offline, events)
+
+		// Rebuild every output, except those using content refused by the lockfile
+		var rebuild []*target
+		for _, t := range targets {
+			if r := t.usesAny(refused); r != "" {
+				log.Printf("Skipping output file %s: content of %s was refused by %s", t.output, r, lock.FileName)
+				continue
 			}
+			rebuild = append(rebuild, t)
 		}
+		return rebuild, true
 	}
 
-	// Process events
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case e := <-events:
-			log.Printf("File changed: %s", e.FilePath)
-
-			// Read all input files
-			contents := make(map[string]string)
-			for _, file := range cfg.InputFiles {
-				// Skip remote files during change events
-				if u, err := url.Parse(file); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
-					// Use cached remote content
-					if content, ok := remoteContents[file]; ok {
-						contents[file] = content
+		case batch := <-batches:
+			// Only outputs that use the changed files are rebuilt, each at most once
+			var affected []*target
+			addAffected := func(t *target) {
+				for _, a := range affected {
+					if a == t {
+						return
 					}
-					continue
 				}
+				affected = append(affected, t)
+			}
 
-				// Handle local file
-				data, err := os.ReadFile(file)
-				if err != nil {
-					log.Printf("Error processing files - failed to read file %s: %v", file, err)
+			// Remote contents are applied first, so that a reload in the same batch can prune them
+			configChanged := false
+			var localChanges []string
+			for _, e := range batch {
+				if !e.IsRemote && configPath != "" && samePath(configPath, e.FilePath) {
+					configChanged = true
+					continue
+				}
+				if !e.IsRemote {
+					log.Printf("%s: %s", describeOp(e.Op), e.FilePath)
+					contents.invalidate(e.FilePath)
+					localChanges = append(localChanges, e.FilePath)
 					continue
 				}
-				contents[file] = string(data)
+
+				log.Printf("File changed: %s", e.FilePath)
+				data, ok := rw.Content(e.FilePath)
+				state, _ := rw.State(e.FilePath)
+				if ok {
+					// Keep the previous content when the new content is refused by the lockfile,
+					// also in the watcher so that later polls and rebuilds do not use the refused version
+					if err := applyLock(lk, data, state, updateLock); err != nil {
+						log.Printf("Error verifying %s: %v", e.FilePath, err)
+						rw.Revert(e.FilePath)
+						continue
+					}
+					if err := lk.Save(lockPath); err != nil {
+						log.Printf("Error saving lockfile: %v", err)
+					}
+					fetcher.store(data, state)
+				}
+				for _, t := range targets {
+					if t.affectedBy(e.FilePath) {
+						addAffected(t)
+					}
+				}
 			}
 
-			// Format contents
-			output, err := formatter.Format(cfg.InputFiles, contents)
-			if err != nil {
-				log.Printf("Error processing files - failed to format content: %v", err)
-				continue
+			reloaded := false
+			if configChanged {
+				var rebuild []*target
+				if rebuild, reloaded = reload(); reloaded {
+					// The new targets replace those picked for remote changes
+					affected = rebuild
+				}
 			}
 
-			// Write to output file
-			if err := os.WriteFile(cfg.OutputFile, []byte(output), 0644); err != nil {
-				log.Printf("Error processing files - failed to write to output file: %v", err)
-				continue
+			// Pick up files created in or removed from watched directories.
+			// A reload has already resolved the inputs of the new targets.
+			if len(localChanges) > 0 && !reloaded {
+				for _, t := range targets {
+					changed, err := t.resolve(generated, ignore)
+					if err != nil {
+						log.Printf("Error processing files - failed to resolve input files: %v", err)
+						continue
+					}
+					if changed {
+						addAffected(t)
+						continue
+					}
+					for _, file := range localChanges {
+						if t.affectedBy(file) {
+							addAffected(t)
+							break
+						}
+					}
+				}
+			}
+
+			// Update the watcher for the new file set
+			if newLocalFiles := localWatchList(targets, configPath); !equalInputs(newLocalFiles, localFiles) {
+				if !reloaded {
+					log.Printf("Watching files: %v", allInputs(targets))
+				}
+				updateWatcher(w, localFiles, newLocalFiles)
+				localFiles = newLocalFiles
+			}
+
+			// Create the sections of the input files of the affected outputs, each at most once
+			sections := make(map[string]formatter.Section)
+			for _, t := range affected {
+				for _, file := range t.inputs {
+					if _, ok := sections[file]; ok {
+						continue
+					}
+
+					// Remote files are not fetched during change events
+					if isRemote(file) {
+						// Use the content kept by the remote watcher
+						if section, ok := remoteSection(file, rw); ok {
+							sections[file] = section
+						}
+						continue
+					}
+
+					// Handle local file; a deleted file is omitted or replaced by a placeholder
+					section, err := contents.section(file, cfg.PlaceholderForMissing())
+					if errors.Is(err, fs.ErrNotExist) {
+						log.Printf("Input file not found, omitting it: %s", file)
+						continue
+					}
+					if err != nil {
+						log.Printf("Error processing files - failed to read file %s: %v", file, err)
+						continue
+					}
+					sections[file] = section
+				}
 			}
 
-			log.Printf("Output file updated: %s", cfg.OutputFile)
+			// Format contents and write the affected output files
+			for _, t := range affected {
+				if err := t.build(sections, stdin, projectRoot); err != nil {
+					log.Printf("Error processing files - %v", err)
+				}
+			}
 		}
 	}
 }
+
+// describeOp returns the log message for a local file event of the given kind
+func describeOp(op watcher.Op) string {
+	switch op {
+	case watcher.Create:
+		return "File created"
+	case watcher.Remove:
+		return "File removed"
+	case watcher.Rename:
+		return "File renamed"
+	case watcher.Error:
+		return "File state unavailable"
+	default:
+		return "File changed"
+	}
+}
+
+// watchLocal starts watching local files and directories with the given watcher
+func watchLocal(ctx context.Context, w watcher.Watcher, files []string, events chan<- watcher.Event) {
+	if err := w.Watch(ctx, files, events); err != nil {
+		log.Printf("Error watching files: %v", err)
+	}
+}
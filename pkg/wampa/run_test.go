@@ -0,0 +1,75 @@
+//go:build small
+
+package wampa
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toms74209200/wampa/pkg/config"
+)
+
+// TestRun_Once tests one-shot builds, which leave the output untouched when an input cannot be read
+func TestRun_Once(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "すべての入力を読めれば出力を書き込む",
+			inputs: []string{"spec.md"},
+			want:   "[//]: # \"filepath: spec.md\"\n# Spec",
+		},
+		{
+			name:    "読めない入力があれば出力を変更しない",
+			inputs:  []string{"spec.md", "missing.md"},
+			want:    "# Old output",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "spec.md"), []byte("# Spec"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			output := filepath.Join(dir, "output.md")
+			if err := os.WriteFile(output, []byte("# Old output"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// Section paths are relative to the directory of the configuration file
+			cfg := config.Config{OutputFile: output}
+			for _, input := range tt.inputs {
+				cfg.InputFiles = append(cfg.InputFiles, filepath.Join(dir, input))
+			}
+			data, err := json.Marshal(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			configFile := filepath.Join(dir, "wampa.json")
+			if err := os.WriteFile(configFile, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err = Run(context.Background(), []string{"--once", "-c", configFile})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package wampa
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errNoStdinContent is returned when the stdin flag is given but nothing is piped in
+var errNoStdinContent = errors.New("standard input flag was specified but no content was provided")
+
+// readStdin reads all content from standard input.
+// Trailing newlines are trimmed so that the section joins like file contents.
+// It fails when stdin is a terminal, when no content was provided or when the content exceeds maxSize bytes.
+func readStdin(f *os.File, maxSize int64) (string, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat standard input: %w", err)
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return "", errNoStdinContent
+	}
+
+	// Read one byte more than allowed to tell a full input from a truncated one
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read standard input: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return "", fmt.Errorf("standard input exceeds maximum allowed size %d", maxSize)
+	}
+	content := strings.TrimRight(string(data), "\r\n")
+	if content == "" {
+		return "", errNoStdinContent
+	}
+
+	return content, nil
+}
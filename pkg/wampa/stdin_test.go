@@ -0,0 +1,54 @@
+//go:build small
+
+package wampa
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReadStdin tests reading standard input redirected from a file
+func TestReadStdin(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "末尾の改行を除く", input: "content\n\n", want: "content"},
+		{name: "上限ちょうどの入力", input: "0123456789", want: "0123456789"},
+		{name: "空の入力", input: "", wantErr: errNoStdinContent},
+		{name: "改行だけの入力", input: "\r\n", wantErr: errNoStdinContent},
+		{name: "上限を超える入力", input: "0123456789a", wantErr: errors.New("standard input exceeds maximum allowed size 10")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "stdin")
+			if err := os.WriteFile(path, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got, err := readStdin(f, 10)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("readStdin() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readStdin() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readStdin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
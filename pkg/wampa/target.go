@@ -0,0 +1,165 @@
+package wampa
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+
+	"github.com/toms74209200/wampa/pkg/config"
+	"github.com/toms74209200/wampa/pkg/formatter"
+	"github.com/toms74209200/wampa/pkg/glob"
+	outputfile "github.com/toms74209200/wampa/pkg/output"
+)
+
+// target is a single output file with its formatter and resolved inputs
+type target struct {
+	output    string
+	entries   []string
+	formatter formatter.Formatter
+	pathStyle formatter.PathStyle
+	inputs    []string
+	dirs      []string
+}
+
+// newTargets creates a target for every output file in the configuration.
+// root is the directory of the configuration file, against which template files are resolved.
+func newTargets(cfg *config.Config, root string) ([]*target, error) {
+	outputs := cfg.Targets()
+	targets := make([]*target, 0, len(outputs))
+	for _, o := range outputs {
+		f, err := newFormatter(o, root)
+		if err != nil {
+			return nil, fmt.Errorf("output file %s: %w", o.Path, err)
+		}
+		style, err := formatter.ParsePathStyle(o.PathStyle)
+		if err != nil {
+			return nil, fmt.Errorf("output file %s: %w", o.Path, err)
+		}
+		targets = append(targets, &target{
+			output:    o.Path,
+			entries:   o.InputFiles,
+			formatter: f,
+			pathStyle: style,
+		})
+	}
+	return targets, nil
+}
+
+// resolve expands the input entries of the target and reports whether its inputs changed.
+// generated are the files written by wampa, such as the outputs of all targets, which are never used as inputs.
+func (t *target) resolve(generated []string, ignore *glob.Ignore) (bool, error) {
+	inputs, dirs, err := resolveInputs(t.entries, generated, ignore)
+	if err != nil {
+		return false, err
+	}
+	changed := !equalInputs(inputs, t.inputs) || !equalInputs(dirs, t.dirs)
+	t.inputs, t.dirs = inputs, dirs
+	return changed, nil
+}
+
+// affectedBy reports whether a change to the given file or directory requires rebuilding the target.
+// Local paths are compared by their absolute form, as reported by the local watcher.
+func (t *target) affectedBy(path string) bool {
+	for _, file := range t.inputs {
+		if samePath(file, path) {
+			return true
+		}
+	}
+	for _, dir := range t.dirs {
+		if samePath(dir, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesAny returns the first of files that is an input of the target, or an empty string
+func (t *target) usesAny(files []string) string {
+	for _, file := range files {
+		if t.affectedBy(file) {
+			return file
+		}
+	}
+	return ""
+}
+
+// samePath reports whether two inputs refer to the same URL or local file
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if isRemote(a) || isRemote(b) {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// render formats the sections of the target's inputs
+func (t *target) render(sections map[string]formatter.Section, stdin *formatter.Section, root string) (string, error) {
+	output, err := formatter.FormatSections(t.formatter, buildSections(t.inputs, sections, stdin, t.pathStyle, root))
+	if err != nil {
+		return "", fmt.Errorf("failed to format content: %w", err)
+	}
+	return output, nil
+}
+
+// build formats the sections of the target's inputs and writes the output file.
+// The output is streamed to the file instead of being built in memory.
+func (t *target) build(sections map[string]formatter.Section, stdin *formatter.Section, root string) error {
+	targetSections := buildSections(t.inputs, sections, stdin, t.pathStyle, root)
+	written, err := outputfile.WriteFrom(t.output, func(w io.Writer) error {
+		return formatter.FormatTo(w, t.formatter, targetSections)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write to output file: %w", err)
+	}
+	if written {
+		log.Printf("Output file updated: %s", t.output)
+	} else {
+		log.Printf("Output file unchanged, skipped writing: %s", t.output)
+	}
+	return nil
+}
+
+// outputFiles returns the output paths of the targets
+func outputFiles(targets []*target) []string {
+	files := make([]string, 0, len(targets))
+	for _, t := range targets {
+		files = append(files, t.output)
+	}
+	return files
+}
+
+// allInputs returns the inputs of all targets without duplicates
+func allInputs(targets []*target) []string {
+	var inputs []string
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		for _, file := range t.inputs {
+			if !seen[file] {
+				seen[file] = true
+				inputs = append(inputs, file)
+			}
+		}
+	}
+	return inputs
+}
+
+// watchedFiles returns the local files and directories to watch for all targets
+// along with their remote URLs
+func watchedFiles(targets []*target) (local, remote []string) {
+	local, remote = splitInputs(allInputs(targets))
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		for _, dir := range t.dirs {
+			if !seen[dir] {
+				seen[dir] = true
+				local = append(local, dir)
+			}
+		}
+	}
+	return local, remote
+}
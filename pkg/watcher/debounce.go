@@ -0,0 +1,117 @@
+package watcher
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MaxWaitWindows is the number of debounce windows after the first event of a batch
+// after which the batch is delivered even if events keep arriving.
+const MaxWaitWindows = 10
+
+// Debouncer coalesces bursts of events into batches.
+// A batch is delivered once no event has arrived for the debounce window,
+// or at the latest MaxWaitWindows windows after its first event, so a steady stream of changes cannot postpone rebuilds forever.
+// Events are never dropped: while the consumer is busy, new events are merged into the pending batch.
+type Debouncer struct {
+	mu     sync.Mutex
+	window time.Duration
+}
+
+// NewDebouncer creates a Debouncer that waits for the given window before delivering a batch.
+// A zero window delivers events as soon as the consumer is ready, still merging them while it is busy.
+func NewDebouncer(window time.Duration) *Debouncer {
+	return &Debouncer{window: window}
+}
+
+// SetWindow changes the debounce window used for subsequent events
+func (d *Debouncer) SetWindow(window time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.window = window
+}
+
+// currentWindow returns the debounce window
+func (d *Debouncer) currentWindow() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.window
+}
+
+// Run reads events from in and sends batches to out until ctx is cancelled.
+// When in is closed, the pending batch is delivered before Run returns.
+func (d *Debouncer) Run(ctx context.Context, in <-chan Event, out chan<- []Event) {
+	var pending batch
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	// ready is out while the pending batch is due, and nil otherwise
+	var ready chan<- []Event
+	// deadline is when the pending batch is due regardless of further events
+	var deadline time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-in:
+			if !ok {
+				if len(pending.events) > 0 {
+					select {
+					case out <- pending.events:
+					case <-ctx.Done():
+					}
+				}
+				return
+			}
+			first := len(pending.events) == 0
+			pending.add(e)
+			// Every event restarts the window until the batch is due
+			if ready == nil {
+				window := d.currentWindow()
+				if first {
+					deadline = time.Now().Add(MaxWaitWindows * window)
+				}
+				if wait := min(window, time.Until(deadline)); wait > 0 {
+					timer.Reset(wait)
+				} else {
+					timer.Stop()
+					ready = out
+				}
+			}
+		case <-timer.C:
+			ready = out
+		case ready <- pending.events:
+			pending = batch{}
+			ready = nil
+		}
+	}
+}
+
+// batch holds pending events with at most one event per file
+type batch struct {
+	events []Event
+	index  map[batchKey]int
+}
+
+// batchKey identifies the file of an event
+type batchKey struct {
+	path   string
+	remote bool
+}
+
+// add merges an event into the batch.
+// Files keep the position of their first event and the kind of change of their latest event.
+func (b *batch) add(e Event) {
+	if b.index == nil {
+		b.index = make(map[batchKey]int)
+	}
+	key := batchKey{path: e.FilePath, remote: e.IsRemote}
+	if i, ok := b.index[key]; ok {
+		b.events[i] = e
+		return
+	}
+	b.index[key] = len(b.events)
+	b.events = append(b.events, e)
+}
@@ -0,0 +1,227 @@
+//go:build small
+
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDebouncer tests coalescing of events into batches
+func TestDebouncer(t *testing.T) {
+	testCases := []struct {
+		name   string
+		window time.Duration
+		events []Event
+		want   []Event
+	}{
+		{
+			name:   "burst is delivered as one batch",
+			window: 50 * time.Millisecond,
+			events: []Event{
+				{FilePath: "a.md", Op: Write},
+				{FilePath: "b.md", Op: Write},
+				{FilePath: "c.md", Op: Create},
+			},
+			want: []Event{
+				{FilePath: "a.md", Op: Write},
+				{FilePath: "b.md", Op: Write},
+				{FilePath: "c.md", Op: Create},
+			},
+		},
+		{
+			name:   "latest change of a file wins",
+			window: 50 * time.Millisecond,
+			events: []Event{
+				{FilePath: "a.md", Op: Write},
+				{FilePath: "b.md", Op: Write},
+				{FilePath: "a.md", Op: Remove},
+			},
+			want: []Event{
+				{FilePath: "a.md", Op: Remove},
+				{FilePath: "b.md", Op: Write},
+			},
+		},
+		{
+			name:   "local and remote files are kept apart",
+			window: 50 * time.Millisecond,
+			events: []Event{
+				{FilePath: "a.md", Op: Write},
+				{FilePath: "a.md", IsRemote: true, Op: Write},
+			},
+			want: []Event{
+				{FilePath: "a.md", Op: Write},
+				{FilePath: "a.md", IsRemote: true, Op: Write},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			in := make(chan Event)
+			out := make(chan []Event)
+			go NewDebouncer(tc.window).Run(ctx, in, out)
+
+			for _, e := range tc.events {
+				in <- e
+			}
+
+			select {
+			case got := <-out:
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("batch = %v, want %v", got, tc.want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for batch")
+			}
+
+			select {
+			case got := <-out:
+				t.Errorf("unexpected batch %v", got)
+			case <-time.After(2 * tc.window):
+			}
+		})
+	}
+}
+
+// TestDebouncer_QuietPeriods tests that events separated by more than the window are delivered separately
+func TestDebouncer_QuietPeriods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan Event)
+	out := make(chan []Event, 2)
+	go NewDebouncer(20*time.Millisecond).Run(ctx, in, out)
+
+	in <- Event{FilePath: "a.md", Op: Write}
+	time.Sleep(100 * time.Millisecond)
+	in <- Event{FilePath: "b.md", Op: Write}
+
+	for _, want := range []string{"a.md", "b.md"} {
+		select {
+		case got := <-out:
+			if len(got) != 1 || got[0].FilePath != want {
+				t.Errorf("batch = %v, want only %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for batch")
+		}
+	}
+}
+
+// TestDebouncer_MaxWait tests that a steady stream of events does not postpone a batch beyond the maximum wait
+func TestDebouncer_MaxWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const window = 20 * time.Millisecond
+	in := make(chan Event)
+	out := make(chan []Event, 1)
+	go NewDebouncer(window).Run(ctx, in, out)
+
+	// Events arrive more often than the window for much longer than the maximum wait
+	start := time.Now()
+	go func() {
+		ticker := time.NewTicker(window / 4)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				select {
+				case in <- Event{FilePath: fmt.Sprintf("%d.md", i), Op: Write}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	select {
+	case got := <-out:
+		elapsed := time.Since(start)
+		if len(got) == 0 {
+			t.Errorf("batch is empty")
+		}
+		if elapsed < MaxWaitWindows*window {
+			t.Errorf("batch delivered after %v, want at least %v", elapsed, MaxWaitWindows*window)
+		}
+		if elapsed > 2*MaxWaitWindows*window+100*time.Millisecond {
+			t.Errorf("batch delivered after %v, want about %v", elapsed, MaxWaitWindows*window)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for batch while events keep arriving")
+	}
+}
+
+// TestDebouncer_BusyConsumer tests that events arriving while the consumer is busy are merged instead of dropped
+func TestDebouncer_BusyConsumer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan Event)
+	out := make(chan []Event)
+	go NewDebouncer(0).Run(ctx, in, out)
+
+	// None of these sends block although nobody reads the batches yet
+	events := []Event{
+		{FilePath: "a.md", Op: Write},
+		{FilePath: "b.md", Op: Write},
+		{FilePath: "c.md", Op: Write},
+	}
+	for _, e := range events {
+		select {
+		case in <- e:
+		case <-time.After(time.Second):
+			t.Fatalf("sending %v blocked", e)
+		}
+	}
+
+	select {
+	case got := <-out:
+		if !reflect.DeepEqual(got, events) {
+			t.Errorf("batch = %v, want %v", got, events)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for batch")
+	}
+}
+
+// TestDebouncer_ClosedInput tests that the pending batch is delivered when the input is closed
+func TestDebouncer_ClosedInput(t *testing.T) {
+	in := make(chan Event)
+	out := make(chan []Event)
+	// The batch is only due after an hour, so it is delivered because the input is closed
+	d := NewDebouncer(0)
+	d.SetWindow(time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		d.Run(context.Background(), in, out)
+		close(done)
+	}()
+
+	in <- Event{FilePath: "a.md", Op: Write}
+	close(in)
+
+	select {
+	case got := <-out:
+		if len(got) != 1 || got[0].FilePath != "a.md" {
+			t.Errorf("batch = %v, want only a.md", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for batch")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after the input was closed")
+	}
+}
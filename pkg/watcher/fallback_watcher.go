@@ -0,0 +1,189 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// FallbackWatcher implements Watcher by delegating to a primary Watcher
+// and switching to a fallback Watcher when the primary reports ErrEventsUnavailable.
+// Files the primary reports in an UnwatchedError are watched by a separate fallback Watcher.
+type FallbackWatcher struct {
+	mu          sync.Mutex
+	active      Watcher
+	newFallback func() (Watcher, error)
+	fellBack    bool
+	// polling watches the files that the primary Watcher cannot watch, and is nil until there are any
+	polling Watcher
+	// ctx, events and files are kept to restart watching with the fallback Watcher
+	ctx    context.Context
+	events chan<- Event
+	files  []string
+}
+
+// NewWatcher creates a Watcher for local files.
+// It uses OS event notification when available and falls back to polling otherwise.
+func NewWatcher(opts Options) (Watcher, error) {
+	newPolling := func() (Watcher, error) {
+		return NewLocalWatcher(opts)
+	}
+
+	primary, err := newEventWatcher(opts)
+	if err != nil {
+		log.Printf("Falling back to polling: %v", err)
+		return newPolling()
+	}
+
+	return NewFallbackWatcher(primary, newPolling), nil
+}
+
+// NewFallbackWatcher creates a new FallbackWatcher instance
+func NewFallbackWatcher(primary Watcher, newFallback func() (Watcher, error)) *FallbackWatcher {
+	return &FallbackWatcher{
+		active:      primary,
+		newFallback: newFallback,
+	}
+}
+
+// Watch starts watching with the primary Watcher, or with the fallback Watcher
+// when the primary cannot use OS event notification
+func (w *FallbackWatcher) Watch(ctx context.Context, files []string, events chan<- Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.active.Watch(ctx, files, events)
+	var unwatched *UnwatchedError
+	if err == nil || errors.As(err, &unwatched) {
+		w.ctx, w.events, w.files = ctx, events, append([]string(nil), files...)
+		if unwatched != nil {
+			return w.poll(unwatched)
+		}
+		return nil
+	}
+	if !errors.Is(err, ErrEventsUnavailable) {
+		return err
+	}
+
+	if err := w.fallBack(ctx, files, events, err); err != nil {
+		return err
+	}
+	w.ctx, w.events, w.files = ctx, events, append([]string(nil), files...)
+	return nil
+}
+
+// fallBack replaces the active Watcher with the fallback Watcher watching files.
+// The caller must hold w.mu
+func (w *FallbackWatcher) fallBack(ctx context.Context, files []string, events chan<- Event, cause error) error {
+	log.Printf("Falling back to polling: %v", cause)
+	if err := w.active.Close(); err != nil {
+		return fmt.Errorf("failed to close watcher: %w", err)
+	}
+	// The fallback Watcher watches every file, including those polled so far
+	if w.polling != nil {
+		if err := w.polling.Close(); err != nil {
+			return fmt.Errorf("failed to close watcher: %w", err)
+		}
+		w.polling = nil
+	}
+
+	fallback, err := w.newFallback()
+	if err != nil {
+		return fmt.Errorf("failed to create fallback watcher: %w", err)
+	}
+	w.active = fallback
+	w.fellBack = true
+
+	return w.active.Watch(ctx, files, events)
+}
+
+// poll watches the files the primary Watcher reported as unwatched with the polling Watcher,
+// creating it on first use. The caller must hold w.mu
+func (w *FallbackWatcher) poll(unwatched *UnwatchedError) error {
+	log.Printf("Polling %s: %v", strings.Join(unwatched.Paths, ", "), unwatched.Err)
+	if w.polling == nil {
+		polling, err := w.newFallback()
+		if err != nil {
+			return fmt.Errorf("failed to create fallback watcher: %w", err)
+		}
+		if err := polling.Watch(w.ctx, unwatched.Paths, w.events); err != nil {
+			return err
+		}
+		w.polling = polling
+		return nil
+	}
+	for _, path := range unwatched.Paths {
+		if err := w.polling.Add(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add starts watching another file with the active Watcher, or polls it when the primary Watcher cannot watch it.
+// When the primary Watcher runs out of OS resources, all files are watched by the fallback Watcher instead.
+func (w *FallbackWatcher) Add(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.active.Add(path)
+	var unwatched *UnwatchedError
+	if err == nil || errors.As(err, &unwatched) {
+		if unwatched != nil {
+			if err := w.poll(unwatched); err != nil {
+				return err
+			}
+		}
+		w.files = append(w.files, path)
+		return nil
+	}
+	if w.fellBack || !errors.Is(err, ErrEventsUnavailable) {
+		return err
+	}
+
+	files := append(append([]string(nil), w.files...), path)
+	if err := w.fallBack(w.ctx, files, w.events, err); err != nil {
+		return err
+	}
+	w.files = files
+	return nil
+}
+
+// Remove stops watching a file with the active Watcher and the polling Watcher
+func (w *FallbackWatcher) Remove(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.active.Remove(path); err != nil {
+		return err
+	}
+	if w.polling != nil {
+		if err := w.polling.Remove(path); err != nil {
+			return err
+		}
+	}
+	files := w.files[:0]
+	for _, file := range w.files {
+		if file != path {
+			files = append(files, file)
+		}
+	}
+	w.files = files
+	return nil
+}
+
+// Close stops watching and cleans up resources
+func (w *FallbackWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.polling != nil {
+		if err := w.polling.Close(); err != nil {
+			return err
+		}
+	}
+	return w.active.Close()
+}
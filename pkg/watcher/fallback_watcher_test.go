@@ -0,0 +1,216 @@
+//go:build small
+
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// mockWatcher implements Watcher for testing
+type mockWatcher struct {
+	watchErr error
+	addErr   error
+	watched  []string
+	closed   bool
+}
+
+func (m *mockWatcher) Watch(ctx context.Context, files []string, events chan<- Event) error {
+	if m.watchErr != nil {
+		return m.watchErr
+	}
+	m.watched = files
+	return nil
+}
+
+func (m *mockWatcher) Add(path string) error {
+	if m.addErr != nil {
+		return m.addErr
+	}
+	m.watched = append(m.watched, path)
+	return nil
+}
+
+func (m *mockWatcher) Remove(path string) error {
+	watched := m.watched[:0]
+	for _, file := range m.watched {
+		if file != path {
+			watched = append(watched, file)
+		}
+	}
+	m.watched = watched
+	return nil
+}
+
+func (m *mockWatcher) Close() error {
+	m.closed = true
+	return nil
+}
+
+// TestFallbackWatcher tests switching to the fallback watcher
+func TestFallbackWatcher(t *testing.T) {
+	testCases := []struct {
+		name         string
+		primaryErr   error
+		wantErr      bool
+		wantFallback bool
+	}{
+		{
+			name:         "primary watcher succeeds",
+			primaryErr:   nil,
+			wantErr:      false,
+			wantFallback: false,
+		},
+		{
+			name:         "events unavailable",
+			primaryErr:   fmt.Errorf("%w: no space left on device", ErrEventsUnavailable),
+			wantErr:      false,
+			wantFallback: true,
+		},
+		{
+			name:         "other primary error",
+			primaryErr:   errors.New("state error"),
+			wantErr:      true,
+			wantFallback: false,
+		},
+		{
+			name:         "unwatched files are polled",
+			primaryErr:   &UnwatchedError{Paths: []string{"test.txt"}, Err: errors.New("no such file or directory")},
+			wantErr:      false,
+			wantFallback: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := &mockWatcher{watchErr: tc.primaryErr}
+			fallback := &mockWatcher{}
+			w := NewFallbackWatcher(primary, func() (Watcher, error) {
+				return fallback, nil
+			})
+
+			files := []string{"test.txt"}
+			err := w.Watch(context.Background(), files, make(chan Event))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Watch() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if got := fallback.watched != nil; got != tc.wantFallback {
+				t.Errorf("fallback used = %v, want %v", got, tc.wantFallback)
+			}
+			_, unwatched := tc.primaryErr.(*UnwatchedError)
+			if tc.wantFallback && primary.closed == unwatched {
+				t.Errorf("primary watcher closed = %v, want %v", primary.closed, !unwatched)
+			}
+
+			if err := w.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		})
+	}
+}
+
+// TestFallbackWatcher_Add tests switching to the fallback watcher when adding a file fails
+func TestFallbackWatcher_Add(t *testing.T) {
+	testCases := []struct {
+		name         string
+		addErr       error
+		wantErr      bool
+		wantFallback bool
+		wantWatched  []string
+	}{
+		{
+			name:        "primary watcher adds the file",
+			wantWatched: []string{"a.txt", "b.txt"},
+		},
+		{
+			name:         "watch limit reached",
+			addErr:       fmt.Errorf("%w: no space left on device", ErrEventsUnavailable),
+			wantFallback: true,
+			wantWatched:  []string{"a.txt", "b.txt"},
+		},
+		{
+			name:    "other add error",
+			addErr:  errors.New("state error"),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := &mockWatcher{addErr: tc.addErr}
+			fallback := &mockWatcher{}
+			w := NewFallbackWatcher(primary, func() (Watcher, error) {
+				return fallback, nil
+			})
+
+			if err := w.Watch(context.Background(), []string{"a.txt"}, make(chan Event)); err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+			err := w.Add("b.txt")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Add() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			active := primary
+			if tc.wantFallback {
+				active = fallback
+				if !primary.closed {
+					t.Error("primary watcher was not closed")
+				}
+			}
+			if fmt.Sprint(active.watched) != fmt.Sprint(tc.wantWatched) {
+				t.Errorf("watched = %v, want %v", active.watched, tc.wantWatched)
+			}
+
+			if err := w.Remove("a.txt"); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if fmt.Sprint(active.watched) != "[b.txt]" {
+				t.Errorf("watched after Remove() = %v, want [b.txt]", active.watched)
+			}
+		})
+	}
+}
+
+// TestFallbackWatcher_AddUnwatched tests polling a file that the primary watcher cannot watch
+func TestFallbackWatcher_AddUnwatched(t *testing.T) {
+	primary := &mockWatcher{}
+	fallback := &mockWatcher{}
+	w := NewFallbackWatcher(primary, func() (Watcher, error) {
+		return fallback, nil
+	})
+
+	if err := w.Watch(context.Background(), []string{"a.txt"}, make(chan Event)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	primary.addErr = &UnwatchedError{Paths: []string{"b.txt"}, Err: errors.New("no such file or directory")}
+	if err := w.Add("b.txt"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if fmt.Sprint(primary.watched) != "[a.txt]" || fmt.Sprint(fallback.watched) != "[b.txt]" {
+		t.Errorf("watched = %v and %v, want [a.txt] and [b.txt]", primary.watched, fallback.watched)
+	}
+	if primary.closed {
+		t.Error("primary watcher was closed")
+	}
+
+	if err := w.Remove("b.txt"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if len(fallback.watched) != 0 {
+		t.Errorf("polled after Remove() = %v, want none", fallback.watched)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if !primary.closed || !fallback.closed {
+		t.Errorf("closed = %v and %v, want both closed", primary.closed, fallback.closed)
+	}
+}
@@ -0,0 +1,388 @@
+package watcher
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// inotifyMask is the set of directory events that can change a watched file.
+// Watching the parent directory catches editors that save by renaming a temp file over the original.
+// IN_MODIFY catches writers that keep the file open and never raise IN_CLOSE_WRITE;
+// the bursts it produces are coalesced by the Debouncer.
+const inotifyMask = syscall.IN_CREATE |
+	syscall.IN_MODIFY |
+	syscall.IN_CLOSE_WRITE |
+	syscall.IN_ATTRIB |
+	syscall.IN_MOVED_TO |
+	syscall.IN_MOVED_FROM |
+	syscall.IN_DELETE
+
+// inotifyEntryMask is the set of events that add or remove a directory entry
+const inotifyEntryMask = syscall.IN_CREATE |
+	syscall.IN_MOVED_TO |
+	syscall.IN_MOVED_FROM |
+	syscall.IN_DELETE
+
+// inotifyWriteMask is the set of events after which the content of a file may differ
+// even when its modification time and size are unchanged
+const inotifyWriteMask = syscall.IN_CLOSE_WRITE |
+	syscall.IN_CREATE |
+	syscall.IN_MOVED_TO
+
+// inotifyEvent is a decoded inotify event
+type inotifyEvent struct {
+	Wd   int
+	Mask uint32
+	Name string
+}
+
+// parseInotifyEvents decodes a buffer read from an inotify file descriptor.
+// This is a pure function that can be easily tested
+func parseInotifyEvents(buf []byte) []inotifyEvent {
+	events := make([]inotifyEvent, 0)
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		raw := buf[offset:]
+		wd := int32(binary.NativeEndian.Uint32(raw[0:4]))
+		mask := binary.NativeEndian.Uint32(raw[4:8])
+		nameLen := int(binary.NativeEndian.Uint32(raw[12:16]))
+
+		end := syscall.SizeofInotifyEvent + nameLen
+		if end > len(raw) {
+			break
+		}
+		// The name is padded with NUL bytes to an aligned length
+		name := strings.TrimRight(string(raw[syscall.SizeofInotifyEvent:end]), "\x00")
+
+		events = append(events, inotifyEvent{Wd: int(wd), Mask: mask, Name: name})
+		offset += end
+	}
+	return events
+}
+
+// InotifyWatcher implements Watcher for local files using Linux inotify
+type InotifyWatcher struct {
+	mu       sync.Mutex
+	fs       FileSystem
+	file     *os.File
+	fd       int
+	states   map[string]FileState
+	dirs     map[int]string
+	watching bool
+	closed   bool
+	// compareContent enables content hashes in file states
+	compareContent bool
+}
+
+// NewInotifyWatcher creates a new InotifyWatcher instance
+func NewInotifyWatcher(opts Options) (*InotifyWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("%w: initializing inotify: %v", ErrEventsUnavailable, err)
+	}
+
+	return &InotifyWatcher{
+		fs: &RealFileSystem{},
+		// A non-blocking descriptor is registered with the runtime poller,
+		// so closing the file interrupts a pending Read
+		file:           os.NewFile(uintptr(fd), "inotify"),
+		fd:             fd,
+		states:         make(map[string]FileState),
+		dirs:           make(map[int]string),
+		compareContent: opts.CompareContent,
+	}, nil
+}
+
+// newEventWatcher creates the event-driven Watcher for this platform
+func newEventWatcher(opts Options) (Watcher, error) {
+	return NewInotifyWatcher(opts)
+}
+
+// Watch starts watching the specified local files
+func (w *InotifyWatcher) Watch(ctx context.Context, files []string, events chan<- Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("watcher is closed")
+	}
+	if w.watching {
+		return fmt.Errorf("watcher is already watching")
+	}
+
+	// Get initial states
+	initialStates, err := readStates(w.fs, files, nil, w.compareContent)
+	if err != nil {
+		return fmt.Errorf("failed to get initial file states: %w", err)
+	}
+
+	// Watch each parent directory once, and tracked directories themselves.
+	// Files in a directory that cannot be watched are reported to the caller instead of being tracked.
+	var unwatched *UnwatchedError
+	for path, state := range initialStates {
+		if err := w.watchDirs(path, state); err != nil {
+			if errors.Is(err, ErrEventsUnavailable) {
+				return err
+			}
+			if unwatched == nil {
+				unwatched = &UnwatchedError{Err: err}
+			}
+			unwatched.Paths = append(unwatched.Paths, path)
+			delete(initialStates, path)
+		}
+	}
+
+	w.states = initialStates
+	w.watching = true
+
+	go w.readEvents(ctx, events)
+	go func() {
+		<-ctx.Done()
+		w.Close()
+	}()
+
+	if unwatched != nil {
+		sort.Strings(unwatched.Paths)
+		return unwatched
+	}
+	return nil
+}
+
+// watchDirs adds the inotify watches needed for a tracked path: its parent directory,
+// and the path itself when it is a directory. The caller must hold w.mu
+func (w *InotifyWatcher) watchDirs(path string, state FileState) error {
+	if err := w.addDirWatch(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if state.IsDir {
+		return w.addDirWatch(path)
+	}
+	return nil
+}
+
+// addDirWatch adds an inotify watch for a directory unless it is already watched.
+// The caller must hold w.mu
+func (w *InotifyWatcher) addDirWatch(dir string) error {
+	for _, watched := range w.dirs {
+		if watched == dir {
+			return nil
+		}
+	}
+
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return watchError(dir, err)
+	}
+	w.dirs[wd] = dir
+	return nil
+}
+
+// watchError describes a failure to add an inotify watch for a directory.
+// Only running out of watches or descriptors, or missing kernel support, makes events unavailable;
+// other errors, such as a missing or unreadable directory, concern that directory alone.
+func watchError(dir string, err error) error {
+	if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENOSYS) {
+		return fmt.Errorf("%w: adding watch for %s: %v", ErrEventsUnavailable, dir, err)
+	}
+	return fmt.Errorf("adding watch for %s: %w", dir, err)
+}
+
+// Add starts watching another local file
+func (w *InotifyWatcher) Add(path string) error {
+	resolved, err := w.fs.ResolvePath(path)
+	if err != nil {
+		return err
+	}
+	states, err := readStates(w.fs, []string{resolved}, nil, w.compareContent)
+	if err != nil {
+		return fmt.Errorf("failed to get file state: %w", err)
+	}
+	state := states[resolved]
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.watching {
+		return ErrNotWatching
+	}
+	if _, ok := w.states[resolved]; ok {
+		return nil
+	}
+	if err := w.watchDirs(resolved, state); err != nil {
+		if errors.Is(err, ErrEventsUnavailable) {
+			return err
+		}
+		return &UnwatchedError{Paths: []string{resolved}, Err: err}
+	}
+	w.states[resolved] = state
+	return nil
+}
+
+// Remove stops watching a local file, and its directories once no tracked file needs them
+func (w *InotifyWatcher) Remove(path string) error {
+	resolved, err := w.fs.ResolvePath(path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.watching {
+		return ErrNotWatching
+	}
+	if _, ok := w.states[resolved]; !ok {
+		return nil
+	}
+	delete(w.states, resolved)
+
+	needed := make(map[string]bool, len(w.states))
+	for p, state := range w.states {
+		needed[filepath.Dir(p)] = true
+		if state.IsDir {
+			needed[p] = true
+		}
+	}
+	for wd, dir := range w.dirs {
+		if !needed[dir] {
+			// The kernel confirms the removal with IN_IGNORED, which deletes the entry
+			if _, err := syscall.InotifyRmWatch(w.fd, uint32(wd)); err != nil {
+				return fmt.Errorf("removing watch for %s: %w", dir, err)
+			}
+			delete(w.dirs, wd)
+		}
+	}
+	return nil
+}
+
+// readEvents reads inotify events until the watcher is closed
+func (w *InotifyWatcher) readEvents(ctx context.Context, events chan<- Event) {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			// Read fails once the file is closed by Close
+			return
+		}
+
+		for _, ev := range parseInotifyEvents(buf[:n]) {
+			fileEvents, err := w.handleEvent(ev)
+			if err != nil {
+				fmt.Printf("Error checking changes: %v\n", err)
+				continue
+			}
+			for _, event := range fileEvents {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// handleEvent updates the tracked states for an inotify event and returns the resulting events
+func (w *InotifyWatcher) handleEvent(ev inotifyEvent) ([]Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if ev.Mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, ev.Wd)
+		return nil, nil
+	}
+
+	// Compare every tracked file when the kernel queue overflowed
+	paths := make([]string, 0, 1)
+	if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		for path := range w.states {
+			paths = append(paths, path)
+		}
+	} else {
+		dir, ok := w.dirs[ev.Wd]
+		if !ok {
+			return nil, nil
+		}
+		path := filepath.Join(dir, ev.Name)
+		if _, tracked := w.states[path]; tracked {
+			paths = append(paths, path)
+		}
+		// Entries added to or removed from a tracked directory change the directory
+		if state, tracked := w.states[dir]; tracked && state.IsDir && ev.Mask&inotifyEntryMask != 0 {
+			paths = append(paths, dir)
+		}
+		if len(paths) == 0 {
+			return nil, nil
+		}
+	}
+
+	previous := make(map[string]FileState, len(paths))
+	for _, path := range paths {
+		previous[path] = w.states[path]
+	}
+	// A write may keep the modification time and size, so hashes are not reused after one
+	reuse := previous
+	if ev.Mask&inotifyWriteMask != 0 {
+		reuse = nil
+	}
+	current, err := readStates(w.fs, paths, reuse, w.compareContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file states: %w", err)
+	}
+
+	for path, state := range current {
+		w.states[path] = state
+	}
+
+	changes := CheckFiles(current, previous)
+	// A file moved away from its path is renamed rather than removed
+	if ev.Mask&syscall.IN_MOVED_FROM != 0 {
+		if i := indexChange(changes, filepath.Join(w.dirs[ev.Wd], ev.Name)); i >= 0 && changes[i].Op == Remove {
+			changes[i].Op = Rename
+		}
+	}
+	// A file created or renamed over the original is replaced even when its mtime matches,
+	// and a tracked directory changes whenever an entry is added or removed.
+	// Content hashes already tell whether a replaced file differs.
+	if ev.Mask&inotifyEntryMask != 0 {
+		for _, path := range paths {
+			if w.compareContent && !current[path].IsDir {
+				continue
+			}
+			if indexChange(changes, path) < 0 {
+				changes = append(changes, FileChange{Path: path, Op: Write})
+			}
+		}
+	}
+	return CreateEvents(changes, false), nil
+}
+
+// indexChange returns the index of the change for the given path, or -1 when there is none
+func indexChange(changes []FileChange, path string) int {
+	for i, change := range changes {
+		if change.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// Close stops watching and cleans up resources
+func (w *InotifyWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.watching = false
+	return w.file.Close()
+}
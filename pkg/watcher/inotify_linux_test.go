@@ -0,0 +1,450 @@
+//go:build small
+
+package watcher
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// encodeInotifyEvent builds a raw inotify event as the kernel would write it
+func encodeInotifyEvent(wd int32, mask uint32, name string) []byte {
+	nameLen := 0
+	if name != "" {
+		// Names are NUL terminated and padded to 16 bytes
+		nameLen = (len(name)/16 + 1) * 16
+	}
+	buf := make([]byte, syscall.SizeofInotifyEvent+nameLen)
+	binary.NativeEndian.PutUint32(buf[0:4], uint32(wd))
+	binary.NativeEndian.PutUint32(buf[4:8], mask)
+	binary.NativeEndian.PutUint32(buf[12:16], uint32(nameLen))
+	copy(buf[syscall.SizeofInotifyEvent:], name)
+	return buf
+}
+
+// TestParseInotifyEvents tests the pure function that decodes inotify events
+func TestParseInotifyEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+		want []inotifyEvent
+	}{
+		{
+			name: "empty buffer",
+			buf:  []byte{},
+			want: []inotifyEvent{},
+		},
+		{
+			name: "single event",
+			buf:  encodeInotifyEvent(1, syscall.IN_CLOSE_WRITE, "spec.md"),
+			want: []inotifyEvent{
+				{Wd: 1, Mask: syscall.IN_CLOSE_WRITE, Name: "spec.md"},
+			},
+		},
+		{
+			name: "multiple events",
+			buf: append(
+				encodeInotifyEvent(1, syscall.IN_MOVED_TO, "a_very_long_file_name.md"),
+				encodeInotifyEvent(2, syscall.IN_IGNORED, "")...,
+			),
+			want: []inotifyEvent{
+				{Wd: 1, Mask: syscall.IN_MOVED_TO, Name: "a_very_long_file_name.md"},
+				{Wd: 2, Mask: syscall.IN_IGNORED, Name: ""},
+			},
+		},
+		{
+			name: "truncated event",
+			buf:  encodeInotifyEvent(1, syscall.IN_CREATE, "spec.md")[:syscall.SizeofInotifyEvent+4],
+			want: []inotifyEvent{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseInotifyEvents(tt.buf)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseInotifyEvents() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseInotifyEvents()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestWatchError tests which failures to add a watch make inotify unavailable
+func TestWatchError(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		wantUnavailable bool
+	}{
+		{name: "watch limit reached", err: syscall.ENOSPC, wantUnavailable: true},
+		{name: "too many open files", err: syscall.EMFILE, wantUnavailable: true},
+		{name: "not supported", err: syscall.ENOSYS, wantUnavailable: true},
+		{name: "missing directory", err: syscall.ENOENT, wantUnavailable: false},
+		{name: "permission denied", err: syscall.EACCES, wantUnavailable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := watchError("/docs", tt.err)
+			if got := errors.Is(err, ErrEventsUnavailable); got != tt.wantUnavailable {
+				t.Errorf("errors.Is(%v, ErrEventsUnavailable) = %v, want %v", err, got, tt.wantUnavailable)
+			}
+			if !tt.wantUnavailable && !errors.Is(err, tt.err) {
+				t.Errorf("watchError() = %v, want it to wrap %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestInotifyWatcher_MissingDirectory tests that files in a directory that cannot be watched
+// are reported as unwatched while the other files are still watched
+func TestInotifyWatcher_MissingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "spec.md")
+	if err := os.WriteFile(path, []byte("initial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing", "rules.md")
+
+	w, err := NewInotifyWatcher(Options{})
+	if err != nil {
+		t.Skipf("inotify unavailable: %v", err)
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	var unwatched *UnwatchedError
+	err = w.Watch(ctx, []string{path, missing}, events)
+	if !errors.As(err, &unwatched) || len(unwatched.Paths) != 1 || unwatched.Paths[0] != missing {
+		t.Fatalf("Watch() error = %v, want %s unwatched", err, missing)
+	}
+	extra := filepath.Join(dir, "other", "extra.md")
+	err = w.Add(extra)
+	if !errors.As(err, &unwatched) || len(unwatched.Paths) != 1 || unwatched.Paths[0] != extra {
+		t.Errorf("Add() error = %v, want %s unwatched", err, extra)
+	}
+
+	// Wait for initial setup
+	time.Sleep(20 * time.Millisecond)
+
+	if err := os.WriteFile(path, []byte("updated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		want := Event{FilePath: path, IsRemote: false, Op: Write}
+		if e != want {
+			t.Errorf("event = %v, want %v", e, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+}
+
+// TestNewWatcher_UnwatchedFiles tests that files whose directory cannot be watched with inotify are polled
+func TestNewWatcher_UnwatchedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "spec.md")
+	if err := os.WriteFile(path, []byte("initial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing", "rules.md")
+	added := filepath.Join(dir, "other", "extra.md")
+
+	w, err := NewWatcher(Options{})
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	if err := w.Watch(ctx, []string{path, missing}, events); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err := w.Add(added); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	for _, file := range []string{missing, added} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("created"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case e := <-events:
+			want := Event{FilePath: file, IsRemote: false, Op: Create}
+			if e != want {
+				t.Errorf("event = %v, want %v", e, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for the creation of %s", file)
+		}
+	}
+
+	// Removed files are no longer polled
+	if err := w.Remove(added); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := os.Remove(added); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %v", e)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// TestInotifyWatcher tests the inotify watcher against a temporary directory
+func TestInotifyWatcher(t *testing.T) {
+	testCases := []struct {
+		name   string
+		action func(dir, path string) error
+		wantOp Op
+	}{
+		{
+			name: "write in place",
+			action: func(dir, path string) error {
+				return os.WriteFile(path, []byte("updated"), 0644)
+			},
+			wantOp: Write,
+		},
+		{
+			name: "atomic save by rename",
+			action: func(dir, path string) error {
+				tmp := filepath.Join(dir, ".spec.md.tmp")
+				if err := os.WriteFile(tmp, []byte("updated"), 0644); err != nil {
+					return err
+				}
+				return os.Rename(tmp, path)
+			},
+			wantOp: Write,
+		},
+		{
+			name: "write through a descriptor kept open",
+			action: func(dir, path string) error {
+				f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+				if err != nil {
+					return err
+				}
+				// The file is closed only after the event is expected
+				time.AfterFunc(2*time.Second, func() { f.Close() })
+				_, err = f.WriteString(" appended")
+				return err
+			},
+			wantOp: Write,
+		},
+		{
+			name: "delete",
+			action: func(dir, path string) error {
+				return os.Remove(path)
+			},
+			wantOp: Remove,
+		},
+		{
+			name: "rename away",
+			action: func(dir, path string) error {
+				return os.Rename(path, filepath.Join(dir, "renamed.md"))
+			},
+			wantOp: Rename,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "spec.md")
+			if err := os.WriteFile(path, []byte("initial"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			w, err := NewInotifyWatcher(Options{})
+			if err != nil {
+				t.Skipf("inotify unavailable: %v", err)
+			}
+			defer w.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			events := make(chan Event, 10)
+			if err := w.Watch(ctx, []string{path}, events); err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+
+			// Wait for initial setup
+			time.Sleep(20 * time.Millisecond)
+
+			// Changes to untracked files in the same directory are ignored
+			if err := os.WriteFile(filepath.Join(dir, "other.md"), []byte("other"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.action(dir, path); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case e := <-events:
+				want := Event{FilePath: path, IsRemote: false, Op: tc.wantOp}
+				if e != want {
+					t.Errorf("event = %v, want %v", e, want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for event")
+			}
+
+			if err := w.Close(); err != nil {
+				t.Errorf("First Close() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Errorf("Second Close() error = %v", err)
+			}
+		})
+	}
+}
+
+// TestInotifyWatcher_AddRemove tests updating the watched files while reading events
+func TestInotifyWatcher_AddRemove(t *testing.T) {
+	dir := t.TempDir()
+	otherDir := t.TempDir()
+	first := filepath.Join(dir, "first.md")
+	second := filepath.Join(otherDir, "second.md")
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("initial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := NewInotifyWatcher(Options{})
+	if err != nil {
+		t.Skipf("inotify unavailable: %v", err)
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	if err := w.Watch(ctx, []string{first}, events); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err := w.Add(second); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := w.Remove(first); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	// Wait for initial setup
+	time.Sleep(20 * time.Millisecond)
+
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("updated"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case e := <-events:
+		want := Event{FilePath: second, IsRemote: false, Op: Write}
+		if e != want {
+			t.Errorf("event = %v, want %v", e, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, watched := range w.dirs {
+		if watched == dir {
+			t.Errorf("directory %s of the removed file is still watched", dir)
+		}
+	}
+}
+
+// TestInotifyWatcher_CompareContent tests that only changes to the content are reported
+func TestInotifyWatcher_CompareContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "spec.md")
+	if err := os.WriteFile(path, []byte("initial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewInotifyWatcher(Options{CompareContent: true})
+	if err != nil {
+		t.Skipf("inotify unavailable: %v", err)
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	if err := w.Watch(ctx, []string{path}, events); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// Wait for initial setup
+	time.Sleep(20 * time.Millisecond)
+
+	// Touching, rewriting and replacing the file with the same content report nothing
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("initial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(dir, ".spec.md.tmp")
+	if err := os.WriteFile(tmp, []byte("initial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The same size with different bytes is reported
+	if err := os.WriteFile(path, []byte("updated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		want := Event{FilePath: path, IsRemote: false, Op: Write}
+		if e != want {
+			t.Errorf("event = %v, want %v", e, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+}
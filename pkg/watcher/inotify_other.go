@@ -0,0 +1,13 @@
+//go:build !linux
+
+package watcher
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// newEventWatcher reports that no event-driven Watcher exists for this platform
+func newEventWatcher(opts Options) (Watcher, error) {
+	return nil, fmt.Errorf("%w: not supported on %s", ErrEventsUnavailable, runtime.GOOS)
+}
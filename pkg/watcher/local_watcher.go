@@ -2,7 +2,10 @@ package watcher
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -24,6 +27,8 @@ func (fs *RealFileSystem) GetFileState(path string) (FileState, error) {
 		Path:    path,
 		ModTime: info.ModTime(),
 		Exists:  true,
+		IsDir:   info.IsDir(),
+		Size:    info.Size(),
 	}, nil
 }
 
@@ -31,6 +36,20 @@ func (fs *RealFileSystem) ResolvePath(path string) (string, error) {
 	return filepath.Abs(path)
 }
 
+func (fs *RealFileSystem) Hash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // LocalWatcher implements Watcher for local files
 type LocalWatcher struct {
 	mu         sync.Mutex
@@ -39,15 +58,18 @@ type LocalWatcher struct {
 	watching   bool
 	done       chan struct{}
 	pollPeriod time.Duration
+	// compareContent enables content hashes in file states
+	compareContent bool
 }
 
 // NewLocalWatcher creates a new LocalWatcher instance
-func NewLocalWatcher() (*LocalWatcher, error) {
+func NewLocalWatcher(opts Options) (*LocalWatcher, error) {
 	return &LocalWatcher{
-		fs:         &RealFileSystem{},
-		states:     make(map[string]FileState),
-		done:       make(chan struct{}),
-		pollPeriod: 100 * time.Millisecond,
+		fs:             &RealFileSystem{},
+		states:         make(map[string]FileState),
+		done:           make(chan struct{}),
+		pollPeriod:     100 * time.Millisecond,
+		compareContent: opts.CompareContent,
 	}, nil
 }
 
@@ -62,7 +84,7 @@ func (w *LocalWatcher) Watch(ctx context.Context, files []string, events chan<-
 	w.mu.Unlock()
 
 	// Get initial states
-	initialStates, err := GetFileStates(w.fs, files)
+	initialStates, err := readStates(w.fs, files, nil, w.compareContent)
 	if err != nil {
 		w.mu.Lock()
 		w.watching = false
@@ -97,15 +119,16 @@ func (w *LocalWatcher) poll(ctx context.Context, events chan<- Event) {
 		case <-w.done:
 			return
 		case <-ticker.C:
-			if err := w.checkChanges(events); err != nil {
+			if err := w.checkChanges(ctx, events); err != nil {
 				fmt.Printf("Error checking changes: %v\n", err)
 			}
 		}
 	}
 }
 
-// checkChanges checks for file changes and sends events
-func (w *LocalWatcher) checkChanges(events chan<- Event) error {
+// checkChanges checks for file changes and sends events.
+// Sending waits for the consumer, so that no change is lost while it is busy.
+func (w *LocalWatcher) checkChanges(ctx context.Context, events chan<- Event) error {
 	w.mu.Lock()
 	paths := make([]string, 0, len(w.states))
 	previousStates := make(map[string]FileState, len(w.states))
@@ -116,36 +139,84 @@ func (w *LocalWatcher) checkChanges(events chan<- Event) error {
 	w.mu.Unlock()
 
 	// Get current states
-	currentStates, err := GetFileStates(w.fs, paths)
+	currentStates, err := readStates(w.fs, paths, previousStates, w.compareContent)
 	if err != nil {
 		return fmt.Errorf("failed to get file states: %w", err)
 	}
 
-	// Check for changes
+	// Update states before sending events to prevent race conditions.
+	// States are stored even without changes, so that hashes are not computed again for a touched file.
+	// Files removed while their states were read are dropped.
 	changes := CheckFiles(currentStates, previousStates)
-	if len(changes) > 0 {
-		// Create and send events
-		fileEvents := CreateEvents(changes, false)
-
-		// Update states before sending events to prevent race conditions
-		w.mu.Lock()
-		w.states = currentStates
-		w.mu.Unlock()
+	w.mu.Lock()
+	tracked := changes[:0]
+	for _, change := range changes {
+		if _, ok := w.states[change.Path]; ok {
+			tracked = append(tracked, change)
+		}
+	}
+	for path, state := range currentStates {
+		if _, ok := w.states[path]; ok {
+			w.states[path] = state
+		}
+	}
+	w.mu.Unlock()
 
-		// Send events without holding the lock
-		for _, event := range fileEvents {
-			select {
-			case events <- event:
-				// Event sent successfully
-			default:
-				return fmt.Errorf("event channel is blocked")
-			}
+	// Create and send events without holding the lock
+	for _, event := range CreateEvents(tracked, false) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return nil
+		case <-w.done:
+			return nil
 		}
 	}
 
 	return nil
 }
 
+// Add starts watching another local file
+func (w *LocalWatcher) Add(path string) error {
+	resolved, err := w.fs.ResolvePath(path)
+	if err != nil {
+		return err
+	}
+	states, err := readStates(w.fs, []string{resolved}, nil, w.compareContent)
+	if err != nil {
+		return fmt.Errorf("failed to get file state: %w", err)
+	}
+	state := states[resolved]
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.watching {
+		return ErrNotWatching
+	}
+	if _, ok := w.states[resolved]; !ok {
+		w.states[resolved] = state
+	}
+	return nil
+}
+
+// Remove stops watching a local file
+func (w *LocalWatcher) Remove(path string) error {
+	resolved, err := w.fs.ResolvePath(path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.watching {
+		return ErrNotWatching
+	}
+	delete(w.states, resolved)
+	return nil
+}
+
 // Close stops watching and cleans up resources
 func (w *LocalWatcher) Close() error {
 	w.mu.Lock()
@@ -5,6 +5,8 @@ package watcher
 import (
 	"context"
 	"errors"
+	"io/fs"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -40,7 +42,31 @@ func TestCheckFiles(t *testing.T) {
 				"file1": {Path: "file1", ModTime: baseTime, Exists: true},
 			},
 			want: []FileChange{
-				{Path: "file1", IsNew: false},
+				{Path: "file1", Op: Write},
+			},
+		},
+		{
+			name: "file deleted",
+			current: map[string]FileState{
+				"file1": {Path: "file1", Exists: false},
+			},
+			previous: map[string]FileState{
+				"file1": {Path: "file1", ModTime: baseTime, Exists: true},
+			},
+			want: []FileChange{
+				{Path: "file1", Op: Remove},
+			},
+		},
+		{
+			name: "file recreated",
+			current: map[string]FileState{
+				"file1": {Path: "file1", ModTime: laterTime, Exists: true},
+			},
+			previous: map[string]FileState{
+				"file1": {Path: "file1", Exists: false},
+			},
+			want: []FileChange{
+				{Path: "file1", Op: Create},
 			},
 		},
 		{
@@ -53,11 +79,44 @@ func TestCheckFiles(t *testing.T) {
 				"file1": {Path: "file1", ModTime: baseTime, Exists: true},
 			},
 			want: []FileChange{
-				{Path: "file2", IsNew: true},
+				{Path: "file2", Op: Create},
 			},
 		},
 		{
-			name: "file removed",
+			name: "touched file with the same content",
+			current: map[string]FileState{
+				"file1": {Path: "file1", ModTime: laterTime, Exists: true, Size: 5, Hash: "abc"},
+			},
+			previous: map[string]FileState{
+				"file1": {Path: "file1", ModTime: baseTime, Exists: true, Size: 5, Hash: "abc"},
+			},
+			want: []FileChange{},
+		},
+		{
+			name: "content changed within the mtime resolution",
+			current: map[string]FileState{
+				"file1": {Path: "file1", ModTime: baseTime, Exists: true, Size: 5, Hash: "def"},
+			},
+			previous: map[string]FileState{
+				"file1": {Path: "file1", ModTime: baseTime, Exists: true, Size: 5, Hash: "abc"},
+			},
+			want: []FileChange{
+				{Path: "file1", Op: Write},
+			},
+		},
+		{
+			name: "missing file is not new",
+			current: map[string]FileState{
+				"file1": {Path: "file1", ModTime: baseTime, Exists: true},
+				"file2": {Path: "file2", Exists: false},
+			},
+			previous: map[string]FileState{
+				"file1": {Path: "file1", ModTime: baseTime, Exists: true},
+			},
+			want: []FileChange{},
+		},
+		{
+			name: "file state lost",
 			current: map[string]FileState{
 				"file1": {Path: "file1", ModTime: baseTime, Exists: true},
 			},
@@ -66,7 +125,7 @@ func TestCheckFiles(t *testing.T) {
 				"file2": {Path: "file2", ModTime: baseTime, Exists: true},
 			},
 			want: []FileChange{
-				{Path: "file2", IsError: true},
+				{Path: "file2", Op: Error},
 			},
 		},
 	}
@@ -98,34 +157,37 @@ func TestCreateEvents(t *testing.T) {
 		{
 			name: "single change",
 			changes: []FileChange{
-				{Path: "file1", IsNew: false},
+				{Path: "file1", Op: Write},
 			},
 			isRemote: false,
 			want: []Event{
-				{FilePath: "file1", IsRemote: false},
+				{FilePath: "file1", IsRemote: false, Op: Write},
 			},
 		},
 		{
 			name: "multiple changes",
 			changes: []FileChange{
-				{Path: "file1", IsNew: false},
-				{Path: "file2", IsNew: true},
+				{Path: "file1", Op: Write},
+				{Path: "file2", Op: Create},
 			},
 			isRemote: true,
 			want: []Event{
-				{FilePath: "file1", IsRemote: true},
-				{FilePath: "file2", IsRemote: true},
+				{FilePath: "file1", IsRemote: true, Op: Write},
+				{FilePath: "file2", IsRemote: true, Op: Create},
 			},
 		},
 		{
-			name: "ignore error changes",
+			name: "keep removals and errors",
 			changes: []FileChange{
-				{Path: "file1", IsNew: false},
-				{Path: "file2", IsError: true},
+				{Path: "file1", Op: Remove},
+				{Path: "file2", Op: Rename},
+				{Path: "file3", Op: Error},
 			},
 			isRemote: false,
 			want: []Event{
-				{FilePath: "file1", IsRemote: false},
+				{FilePath: "file1", IsRemote: false, Op: Remove},
+				{FilePath: "file2", IsRemote: false, Op: Rename},
+				{FilePath: "file3", IsRemote: false, Op: Error},
 			},
 		},
 	}
@@ -140,17 +202,104 @@ func TestCreateEvents(t *testing.T) {
 	}
 }
 
+// TestHashStates tests that content hashes are computed only for modified files
+func TestHashStates(t *testing.T) {
+	baseTime := time.Now()
+	laterTime := baseTime.Add(time.Second)
+
+	tests := []struct {
+		name      string
+		current   FileState
+		previous  map[string]FileState
+		want      FileState
+		wantCalls int
+	}{
+		{
+			name:      "first state is hashed",
+			current:   FileState{Path: "/mock/a.txt", ModTime: baseTime, Exists: true, Size: 3},
+			want:      FileState{Path: "/mock/a.txt", ModTime: baseTime, Exists: true, Size: 3, Hash: "new"},
+			wantCalls: 1,
+		},
+		{
+			name:    "unmodified file reuses the previous hash",
+			current: FileState{Path: "/mock/a.txt", ModTime: baseTime, Exists: true, Size: 3},
+			previous: map[string]FileState{
+				"/mock/a.txt": {Path: "/mock/a.txt", ModTime: baseTime, Exists: true, Size: 3, Hash: "old"},
+			},
+			want:      FileState{Path: "/mock/a.txt", ModTime: baseTime, Exists: true, Size: 3, Hash: "old"},
+			wantCalls: 0,
+		},
+		{
+			name:    "modified file is hashed again",
+			current: FileState{Path: "/mock/a.txt", ModTime: laterTime, Exists: true, Size: 3},
+			previous: map[string]FileState{
+				"/mock/a.txt": {Path: "/mock/a.txt", ModTime: baseTime, Exists: true, Size: 3, Hash: "old"},
+			},
+			want:      FileState{Path: "/mock/a.txt", ModTime: laterTime, Exists: true, Size: 3, Hash: "new"},
+			wantCalls: 1,
+		},
+		{
+			name:      "directories are not hashed",
+			current:   FileState{Path: "/mock/a.txt", ModTime: baseTime, Exists: true, IsDir: true},
+			want:      FileState{Path: "/mock/a.txt", ModTime: baseTime, Exists: true, IsDir: true},
+			wantCalls: 0,
+		},
+		{
+			name:      "missing files are not hashed",
+			current:   FileState{Path: "/mock/a.txt", Exists: false},
+			want:      FileState{Path: "/mock/a.txt", Exists: false},
+			wantCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFS := NewMockFileSystem()
+			mockFS.SetContent("/mock/a.txt", tt.current, "new")
+
+			current := map[string]FileState{"/mock/a.txt": tt.current}
+			if err := HashStates(mockFS, current, tt.previous); err != nil {
+				t.Fatalf("HashStates() error = %v", err)
+			}
+			if got := current["/mock/a.txt"]; got != tt.want {
+				t.Errorf("HashStates() state = %+v, want %+v", got, tt.want)
+			}
+			if got := mockFS.HashCalls(); got != tt.wantCalls {
+				t.Errorf("hash calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestHashStates_Removed tests a file that disappears before it is hashed
+func TestHashStates_Removed(t *testing.T) {
+	mockFS := NewMockFileSystem()
+	current := map[string]FileState{
+		"/mock/a.txt": {Path: "/mock/a.txt", ModTime: time.Now(), Exists: true, Size: 3},
+	}
+	if err := HashStates(mockFS, current, nil); err != nil {
+		t.Fatalf("HashStates() error = %v", err)
+	}
+	want := FileState{Path: "/mock/a.txt", Exists: false}
+	if got := current["/mock/a.txt"]; got != want {
+		t.Errorf("HashStates() state = %+v, want %+v", got, want)
+	}
+}
+
 // MockFileSystem implements FileSystem interface for testing
 type MockFileSystem struct {
-	mu     sync.Mutex
-	states map[string]FileState
-	errors map[string]error
+	mu        sync.Mutex
+	states    map[string]FileState
+	errors    map[string]error
+	hashes    map[string]string
+	hashCalls int
 }
 
 func NewMockFileSystem() *MockFileSystem {
 	return &MockFileSystem{
 		states: make(map[string]FileState),
 		errors: make(map[string]error),
+		hashes: make(map[string]string),
 	}
 }
 
@@ -176,9 +325,39 @@ func (m *MockFileSystem) ResolvePath(path string) (string, error) {
 	if err, ok := m.errors[path]; ok && err != nil {
 		return "", err
 	}
+	// Like filepath.Abs, resolving an already resolved path returns it unchanged
+	if strings.HasPrefix(path, "/mock/") {
+		return path, nil
+	}
 	return "/mock/" + path, nil
 }
 
+func (m *MockFileSystem) Hash(path string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hashCalls++
+	if hash, ok := m.hashes[path]; ok {
+		return hash, nil
+	}
+	return "", fs.ErrNotExist
+}
+
+// SetContent sets the state of a file along with the hash of its content
+func (m *MockFileSystem) SetContent(path string, state FileState, hash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[path] = state
+	m.hashes[path] = hash
+}
+
+// HashCalls returns the number of hashes computed so far
+func (m *MockFileSystem) HashCalls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hashCalls
+}
+
 func (m *MockFileSystem) SetFileState(path string, state FileState) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -358,6 +537,161 @@ func TestLocalWatcher(t *testing.T) {
 	}
 }
 
+// TestLocalWatcher_BlockedConsumer tests that changes are kept while nobody reads the events
+func TestLocalWatcher_BlockedConsumer(t *testing.T) {
+	now := time.Now()
+	mockFS := NewMockFileSystem()
+	mockFS.SetFileState("/mock/a.txt", FileState{Path: "/mock/a.txt", ModTime: now, Exists: true})
+
+	w := &LocalWatcher{
+		fs:         mockFS,
+		states:     make(map[string]FileState),
+		done:       make(chan struct{}),
+		pollPeriod: 10 * time.Millisecond,
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event)
+	if err := w.Watch(ctx, []string{"a.txt"}, events); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// The change is reported even though it happened several polls before reading
+	mockFS.SetFileState("/mock/a.txt", FileState{Path: "/mock/a.txt", ModTime: now.Add(time.Second), Exists: true})
+	time.Sleep(100 * time.Millisecond)
+
+	select {
+	case e := <-events:
+		want := Event{FilePath: "/mock/a.txt", Op: Write}
+		if e != want {
+			t.Errorf("event = %v, want %v", e, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+}
+
+// TestLocalWatcher_CompareContent tests that only changes to the content are reported
+func TestLocalWatcher_CompareContent(t *testing.T) {
+	now := time.Now()
+	mockFS := NewMockFileSystem()
+	mockFS.SetContent("/mock/a.txt", FileState{Path: "/mock/a.txt", ModTime: now, Exists: true, Size: 3}, "v1")
+
+	w, err := NewLocalWatcher(Options{CompareContent: true})
+	if err != nil {
+		t.Fatalf("NewLocalWatcher() error = %v", err)
+	}
+	w.fs = mockFS
+	w.pollPeriod = 10 * time.Millisecond
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	if err := w.Watch(ctx, []string{"a.txt"}, events); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// Touching the file hashes it once and reports nothing
+	mockFS.SetContent("/mock/a.txt", FileState{Path: "/mock/a.txt", ModTime: now.Add(time.Second), Exists: true, Size: 3}, "v1")
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %v after touching", e)
+	default:
+	}
+	if got := mockFS.HashCalls(); got != 2 {
+		t.Errorf("hash calls = %d, want 2", got)
+	}
+
+	// Changing the content is reported
+	mockFS.SetContent("/mock/a.txt", FileState{Path: "/mock/a.txt", ModTime: now.Add(2 * time.Second), Exists: true, Size: 3}, "v2")
+	select {
+	case e := <-events:
+		want := Event{FilePath: "/mock/a.txt", Op: Write}
+		if e != want {
+			t.Errorf("event = %v, want %v", e, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+	if got := mockFS.HashCalls(); got != 3 {
+		t.Errorf("hash calls = %d, want 3", got)
+	}
+
+	// Later polls of the unchanged file do not hash it again
+	time.Sleep(100 * time.Millisecond)
+	if got := mockFS.HashCalls(); got != 3 {
+		t.Errorf("hash calls after polling = %d, want 3", got)
+	}
+}
+
+// TestLocalWatcher_AddRemove tests updating the watched files while polling
+func TestLocalWatcher_AddRemove(t *testing.T) {
+	now := time.Now()
+	mockFS := NewMockFileSystem()
+	for _, path := range []string{"/mock/a.txt", "/mock/b.txt"} {
+		mockFS.SetFileState(path, FileState{Path: path, ModTime: now, Exists: true})
+	}
+
+	w := &LocalWatcher{
+		fs:         mockFS,
+		states:     make(map[string]FileState),
+		done:       make(chan struct{}),
+		pollPeriod: 10 * time.Millisecond,
+	}
+	defer w.Close()
+
+	if err := w.Add("b.txt"); !errors.Is(err, ErrNotWatching) {
+		t.Fatalf("Add() before Watch() error = %v, want %v", err, ErrNotWatching)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	if err := w.Watch(ctx, []string{"a.txt"}, events); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err := w.Add("b.txt"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := w.Add("b.txt"); err != nil {
+		t.Fatalf("Add() of a watched file error = %v", err)
+	}
+	if err := w.Remove("a.txt"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := w.Remove("missing.txt"); err != nil {
+		t.Fatalf("Remove() of an unwatched file error = %v", err)
+	}
+
+	// Only the added file is reported
+	later := now.Add(time.Second)
+	mockFS.SetFileState("/mock/a.txt", FileState{Path: "/mock/a.txt", ModTime: later, Exists: true})
+	mockFS.SetFileState("/mock/b.txt", FileState{Path: "/mock/b.txt", ModTime: later, Exists: true})
+
+	select {
+	case e := <-events:
+		want := Event{FilePath: "/mock/b.txt", Op: Write}
+		if e != want {
+			t.Errorf("event = %v, want %v", e, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 // Helper functions for comparing test results
 func compareFileChanges(a, b []FileChange) bool {
 	if len(a) != len(b) {
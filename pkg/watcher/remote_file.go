@@ -37,6 +37,28 @@ func CreateRemoteFileRequest(ctx context.Context, url string, headers map[string
 	return req, nil
 }
 
+// CreateConditionalRemoteFileRequest creates an HTTP request that only returns content
+// when the remote file differs from the given state.
+// This is a pure function with no side effects.
+// ctx: context for cancellation
+// state: metadata from the previous response; its ETag and LastModified become validators
+// headers: optional HTTP headers (e.g., User-Agent)
+func CreateConditionalRemoteFileRequest(ctx context.Context, state RemoteFileState, headers map[string]string) (*http.Request, error) {
+	req, err := CreateRemoteFileRequest(ctx, state.URL, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.ETag != "" {
+		req.Header.Set("If-None-Match", state.ETag)
+	}
+	if state.LastModified != "" {
+		req.Header.Set("If-Modified-Since", state.LastModified)
+	}
+
+	return req, nil
+}
+
 // ProcessRemoteFileResponse processes an HTTP response to extract file content and metadata.
 // This is a pure function with no side effects.
 // resp: HTTP response to process
@@ -77,6 +77,59 @@ func TestCreateRemoteFileRequest(t *testing.T) {
 	}
 }
 
+func TestCreateConditionalRemoteFileRequest(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		state                 RemoteFileState
+		expectError           bool
+		expectIfNoneMatch     string
+		expectIfModifiedSince string
+	}{
+		{
+			name: "with validators",
+			state: RemoteFileState{
+				URL:          "http://example.com/test.txt",
+				ETag:         "\"abc123\"",
+				LastModified: "Mon, 02 Jan 2006 15:04:05 GMT",
+			},
+			expectError:           false,
+			expectIfNoneMatch:     "\"abc123\"",
+			expectIfModifiedSince: "Mon, 02 Jan 2006 15:04:05 GMT",
+		},
+		{
+			name:                  "without validators",
+			state:                 RemoteFileState{URL: "http://example.com/test.txt"},
+			expectError:           false,
+			expectIfNoneMatch:     "",
+			expectIfModifiedSince: "",
+		},
+		{
+			name:        "invalid url",
+			state:       RemoteFileState{URL: "://invalid"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := CreateConditionalRemoteFileRequest(context.Background(), tc.state, nil)
+			if (err != nil) != tc.expectError {
+				t.Fatalf("CreateConditionalRemoteFileRequest() error = %v, expectError %v", err, tc.expectError)
+			}
+			if tc.expectError {
+				return
+			}
+
+			if got := req.Header.Get("If-None-Match"); got != tc.expectIfNoneMatch {
+				t.Errorf("Expected If-None-Match %q, got %q", tc.expectIfNoneMatch, got)
+			}
+			if got := req.Header.Get("If-Modified-Since"); got != tc.expectIfModifiedSince {
+				t.Errorf("Expected If-Modified-Since %q, got %q", tc.expectIfModifiedSince, got)
+			}
+		})
+	}
+}
+
 // createTestResponse creates a mock HTTP response for testing
 func createTestResponse(statusCode int, body string, headers map[string]string) *http.Response {
 	header := http.Header{}
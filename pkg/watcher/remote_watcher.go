@@ -0,0 +1,278 @@
+package watcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPClient defines the port for executing HTTP requests
+type HTTPClient interface {
+	// Do sends an HTTP request and returns an HTTP response
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// remoteEntry holds the last known content of a remote file
+type remoteEntry struct {
+	state   RemoteFileState
+	content []byte
+	hash    [sha256.Size]byte
+}
+
+// replacedEntry is the entry that the last reported change of a remote file replaced
+type replacedEntry struct {
+	entry remoteEntry
+	// known is false when no content was known before the change
+	known bool
+}
+
+// RemoteWatcher implements Watcher for remote files using conditional GET polling
+type RemoteWatcher struct {
+	mu           sync.Mutex
+	client       HTTPClient
+	maxSize      int64
+	pollInterval func(url string) time.Duration
+	entries      map[string]remoteEntry
+	watching     bool
+	done         chan struct{}
+	// ctx and events are kept so that Add can start polling more URLs
+	ctx    context.Context
+	events chan<- Event
+	// pollers holds the channel that stops polling of each watched URL
+	pollers map[string]chan struct{}
+	// replaced holds the entries replaced by reported changes, so that they can be reverted
+	replaced map[string]replacedEntry
+}
+
+// NewRemoteWatcher creates a new RemoteWatcher instance.
+// pollInterval returns the interval between checks of each URL.
+func NewRemoteWatcher(client HTTPClient, maxSize int64, pollInterval func(url string) time.Duration) (*RemoteWatcher, error) {
+	if client == nil {
+		return nil, fmt.Errorf("HTTP client is nil")
+	}
+	if pollInterval == nil {
+		return nil, fmt.Errorf("poll interval function is nil")
+	}
+
+	return &RemoteWatcher{
+		client:       client,
+		maxSize:      maxSize,
+		pollInterval: pollInterval,
+		entries:      make(map[string]remoteEntry),
+		replaced:     make(map[string]replacedEntry),
+		done:         make(chan struct{}),
+		pollers:      make(map[string]chan struct{}),
+	}, nil
+}
+
+// SetState records the last known content and metadata of a remote file,
+// so that polling starts with a conditional request instead of a full download
+func (w *RemoteWatcher) SetState(content []byte, state RemoteFileState) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.entries[state.URL] = remoteEntry{
+		state:   state,
+		content: content,
+		hash:    sha256.Sum256(content),
+	}
+	delete(w.replaced, state.URL)
+}
+
+// Revert puts back the content and metadata of a remote file from before its last reported change.
+// Callers use it when they refuse the new content, so that it is neither returned by Content and State
+// nor used as the base of conditional requests, which would hide the refused change from later polls.
+func (w *RemoteWatcher) Revert(url string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	replaced, ok := w.replaced[url]
+	if !ok {
+		return
+	}
+	delete(w.replaced, url)
+	if replaced.known {
+		w.entries[url] = replaced.entry
+	} else {
+		delete(w.entries, url)
+	}
+}
+
+// Content returns the last fetched content of a remote file
+func (w *RemoteWatcher) Content(url string) ([]byte, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry, ok := w.entries[url]
+	if !ok {
+		return nil, false
+	}
+	return entry.content, true
+}
+
+// State returns the metadata of the last fetched content of a remote file
+func (w *RemoteWatcher) State(url string) (RemoteFileState, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry, ok := w.entries[url]
+	if !ok {
+		return RemoteFileState{}, false
+	}
+	return entry.state, true
+}
+
+// Watch starts polling the specified remote files
+func (w *RemoteWatcher) Watch(ctx context.Context, urls []string, events chan<- Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watching {
+		return fmt.Errorf("watcher is already watching")
+	}
+	w.watching = true
+	w.ctx, w.events = ctx, events
+
+	for _, url := range urls {
+		w.startPolling(url)
+	}
+
+	return nil
+}
+
+// startPolling starts polling a URL unless it is already polled.
+// The caller must hold w.mu
+func (w *RemoteWatcher) startPolling(url string) {
+	if _, ok := w.pollers[url]; ok {
+		return
+	}
+	stop := make(chan struct{})
+	w.pollers[url] = stop
+	go w.poll(w.ctx, url, w.events, stop)
+}
+
+// Add starts polling another remote file
+func (w *RemoteWatcher) Add(url string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.watching {
+		return ErrNotWatching
+	}
+	w.startPolling(url)
+	return nil
+}
+
+// Remove stops polling a remote file and forgets its content
+func (w *RemoteWatcher) Remove(url string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.watching {
+		return ErrNotWatching
+	}
+	if stop, ok := w.pollers[url]; ok {
+		close(stop)
+		delete(w.pollers, url)
+	}
+	delete(w.entries, url)
+	delete(w.replaced, url)
+	return nil
+}
+
+// poll periodically checks a single remote file for changes until stop is closed
+func (w *RemoteWatcher) poll(ctx context.Context, url string, events chan<- Event, stop <-chan struct{}) {
+	ticker := time.NewTicker(w.pollInterval(url))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			changed, err := w.checkChanges(ctx, url)
+			if err != nil {
+				log.Printf("Error checking changes: %v", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			select {
+			case events <- Event{FilePath: url, IsRemote: true, Op: Write}:
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+// checkChanges sends a conditional request for a remote file and reports whether its content changed
+func (w *RemoteWatcher) checkChanges(ctx context.Context, url string) (bool, error) {
+	w.mu.Lock()
+	previous, known := w.entries[url]
+	w.mu.Unlock()
+
+	state := previous.state
+	state.URL = url
+	req, err := CreateConditionalRemoteFileRequest(ctx, state, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+
+	content, newState, err := ProcessRemoteFileResponse(resp, url, w.maxSize)
+	if err != nil {
+		return false, err
+	}
+
+	hash := sha256.Sum256(content)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// The URL may have been removed while it was fetched
+	if _, polled := w.pollers[url]; !polled {
+		return false, nil
+	}
+	w.entries[url] = remoteEntry{state: newState, content: content, hash: hash}
+
+	changed := !known || hash != previous.hash
+	if changed {
+		w.replaced[url] = replacedEntry{entry: previous, known: known}
+	}
+	return changed, nil
+}
+
+// Close stops watching and cleans up resources
+func (w *RemoteWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.watching {
+		return nil
+	}
+
+	close(w.done)
+	w.watching = false
+	return nil
+}
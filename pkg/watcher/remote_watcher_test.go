@@ -0,0 +1,260 @@
+//go:build small
+
+package watcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockHTTPClient implements HTTPClient for testing
+type mockHTTPClient struct {
+	mu        sync.Mutex
+	responses []*http.Response
+	requests  []*http.Request
+}
+
+func (m *mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, req)
+	if len(m.responses) == 0 {
+		return createTestResponse(http.StatusNotModified, "", nil), nil
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return resp, nil
+}
+
+func (m *mockHTTPClient) Requests() []*http.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*http.Request(nil), m.requests...)
+}
+
+// TestRemoteWatcher tests the remote watcher implementation
+func TestRemoteWatcher(t *testing.T) {
+	const testURL = "http://example.com/rules.md"
+
+	testCases := []struct {
+		name        string
+		responses   []*http.Response
+		wantEvents  int
+		wantContent string
+	}{
+		{
+			name: "not modified",
+			responses: []*http.Response{
+				createTestResponse(http.StatusNotModified, "", nil),
+			},
+			wantEvents:  0,
+			wantContent: "initial",
+		},
+		{
+			name: "content changed",
+			responses: []*http.Response{
+				createTestResponse(http.StatusOK, "updated", map[string]string{"ETag": "\"v2\""}),
+			},
+			wantEvents:  1,
+			wantContent: "updated",
+		},
+		{
+			name: "same content with new validators",
+			responses: []*http.Response{
+				createTestResponse(http.StatusOK, "initial", map[string]string{"ETag": "\"v2\""}),
+			},
+			wantEvents:  0,
+			wantContent: "initial",
+		},
+		{
+			name: "server error",
+			responses: []*http.Response{
+				createTestResponse(http.StatusBadGateway, "Bad Gateway", nil),
+			},
+			wantEvents:  0,
+			wantContent: "initial",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &mockHTTPClient{responses: tc.responses}
+			w, err := NewRemoteWatcher(client, 1024, func(string) time.Duration {
+				return 10 * time.Millisecond
+			})
+			if err != nil {
+				t.Fatalf("NewRemoteWatcher() error = %v", err)
+			}
+			w.SetState([]byte("initial"), RemoteFileState{
+				URL:          testURL,
+				ETag:         "\"v1\"",
+				LastModified: "Mon, 02 Jan 2006 15:04:05 GMT",
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			events := make(chan Event, 10)
+			if err := w.Watch(ctx, []string{testURL}, events); err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+
+			// Wait for several polls
+			time.Sleep(100 * time.Millisecond)
+			if err := w.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+
+			if got := len(events); got != tc.wantEvents {
+				t.Errorf("received %d events, want %d", got, tc.wantEvents)
+			}
+			for i := 0; i < tc.wantEvents; i++ {
+				e := <-events
+				want := Event{FilePath: testURL, IsRemote: true, Op: Write}
+				if e != want {
+					t.Errorf("event = %v, want %v", e, want)
+				}
+			}
+
+			content, ok := w.Content(testURL)
+			if !ok || string(content) != tc.wantContent {
+				t.Errorf("Content() = %q, %v, want %q", content, ok, tc.wantContent)
+			}
+
+			requests := client.Requests()
+			if len(requests) == 0 {
+				t.Fatal("no requests were sent")
+			}
+			if got := requests[0].Header.Get("If-None-Match"); got != "\"v1\"" {
+				t.Errorf("If-None-Match = %q, want %q", got, "\"v1\"")
+			}
+			if got := requests[0].Header.Get("If-Modified-Since"); got != "Mon, 02 Jan 2006 15:04:05 GMT" {
+				t.Errorf("If-Modified-Since = %q, want %q", got, "Mon, 02 Jan 2006 15:04:05 GMT")
+			}
+		})
+	}
+}
+
+// TestRemoteWatcher_Revert tests that a refused change is forgotten and not used for conditional requests
+func TestRemoteWatcher_Revert(t *testing.T) {
+	const testURL = "http://example.com/rules.md"
+
+	testCases := []struct {
+		name        string
+		initial     bool
+		wantContent string
+		wantETag    string
+	}{
+		{name: "previous content is restored", initial: true, wantContent: "initial", wantETag: "\"v1\""},
+		{name: "unknown file is forgotten", initial: false, wantETag: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &mockHTTPClient{responses: []*http.Response{
+				createTestResponse(http.StatusOK, "refused", map[string]string{"ETag": "\"v2\""}),
+			}}
+			w, err := NewRemoteWatcher(client, 1024, func(string) time.Duration {
+				return 10 * time.Millisecond
+			})
+			if err != nil {
+				t.Fatalf("NewRemoteWatcher() error = %v", err)
+			}
+			if tc.initial {
+				w.SetState([]byte("initial"), RemoteFileState{URL: testURL, ETag: "\"v1\""})
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events := make(chan Event, 10)
+			if err := w.Watch(ctx, []string{testURL}, events); err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+
+			select {
+			case <-events:
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for the change")
+			}
+			w.Revert(testURL)
+
+			content, ok := w.Content(testURL)
+			if ok != tc.initial || string(content) != tc.wantContent {
+				t.Errorf("Content() = %q, %v, want %q, %v", content, ok, tc.wantContent, tc.initial)
+			}
+			if state, _ := w.State(testURL); state.ETag != tc.wantETag {
+				t.Errorf("State().ETag = %q, want %q", state.ETag, tc.wantETag)
+			}
+
+			// The next poll is based on the restored validators
+			before := len(client.Requests())
+			time.Sleep(50 * time.Millisecond)
+			if err := w.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+			requests := client.Requests()
+			if len(requests) <= before {
+				t.Fatal("no request was sent after Revert()")
+			}
+			if got := requests[len(requests)-1].Header.Get("If-None-Match"); got != tc.wantETag {
+				t.Errorf("If-None-Match = %q, want %q", got, tc.wantETag)
+			}
+		})
+	}
+}
+
+// TestRemoteWatcher_AddRemove tests updating the polled URLs while watching
+func TestRemoteWatcher_AddRemove(t *testing.T) {
+	const (
+		firstURL  = "http://example.com/first.md"
+		secondURL = "http://example.com/second.md"
+	)
+
+	client := &mockHTTPClient{}
+	w, err := NewRemoteWatcher(client, 1024, func(string) time.Duration {
+		return 10 * time.Millisecond
+	})
+	if err != nil {
+		t.Fatalf("NewRemoteWatcher() error = %v", err)
+	}
+	defer w.Close()
+
+	if err := w.Add(secondURL); !errors.Is(err, ErrNotWatching) {
+		t.Fatalf("Add() before Watch() error = %v, want %v", err, ErrNotWatching)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := w.Watch(ctx, []string{firstURL}, make(chan Event, 10)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err := w.Add(secondURL); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := w.Remove(firstURL); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	// Wait for several polls
+	time.Sleep(100 * time.Millisecond)
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, req := range client.Requests() {
+		counts[req.URL.String()]++
+	}
+	if counts[secondURL] == 0 {
+		t.Errorf("added URL %s was not polled", secondURL)
+	}
+	if counts[firstURL] > 1 {
+		t.Errorf("removed URL %s was polled %d times", firstURL, counts[firstURL])
+	}
+}
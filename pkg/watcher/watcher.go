@@ -3,13 +3,75 @@ package watcher
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"strings"
 	"time"
 )
 
+// ErrEventsUnavailable is returned when OS event notification cannot be used,
+// for example when inotify is missing or its watch limit is reached
+var ErrEventsUnavailable = errors.New("file system events unavailable")
+
+// UnwatchedError is returned by Watch and Add of an event-driven Watcher
+// when some files cannot get OS events, for example because their directory does not exist.
+// The other files are watched; FallbackWatcher polls the files in Paths instead.
+type UnwatchedError struct {
+	// Paths are the files that are not watched
+	Paths []string
+	// Err is the cause for the first of them
+	Err error
+}
+
+func (e *UnwatchedError) Error() string {
+	return fmt.Sprintf("cannot watch %s: %v", strings.Join(e.Paths, ", "), e.Err)
+}
+
+func (e *UnwatchedError) Unwrap() error {
+	return e.Err
+}
+
+// Op describes the kind of change reported by an Event
+type Op int
+
+// Kinds of changes
+const (
+	// Create means that the file came into existence
+	Create Op = iota + 1
+	// Write means that the content or modification time of the file changed
+	Write
+	// Remove means that the file no longer exists
+	Remove
+	// Rename means that the file was moved away from its path
+	Rename
+	// Error means that the state of the file is no longer available
+	Error
+)
+
+// String returns the name of the operation
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "CREATE"
+	case Write:
+		return "WRITE"
+	case Remove:
+		return "REMOVE"
+	case Rename:
+		return "RENAME"
+	case Error:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("Op(%d)", int(op))
+	}
+}
+
 // Event represents a file event
 type Event struct {
 	FilePath string
 	IsRemote bool
+	Op       Op
 }
 
 // FileState represents the state of a file at a point in time
@@ -17,21 +79,42 @@ type FileState struct {
 	Path    string
 	ModTime time.Time
 	Exists  bool
+	// IsDir is true when the path is a directory, whose ModTime changes as entries are added or removed
+	IsDir bool
+	// Size is the size of the file in bytes
+	Size int64
+	// Hash is the hex-encoded SHA-256 hash of the content, or empty when it was not computed
+	Hash string
 }
 
-// Equal compares two FileStates for equality
+// Equal compares two FileStates for equality.
+// When both states have content hashes, the content decides regardless of the modification times.
 func (fs FileState) Equal(other FileState) bool {
-	return fs.Path == other.Path &&
-		fs.ModTime.Equal(other.ModTime) &&
-		fs.Exists == other.Exists
+	if fs.Path != other.Path || fs.Exists != other.Exists {
+		return false
+	}
+	if fs.Hash != "" && other.Hash != "" {
+		return fs.Size == other.Size && fs.Hash == other.Hash
+	}
+	return fs.ModTime.Equal(other.ModTime)
 }
 
 // FileSystem defines the port for file system operations
 type FileSystem interface {
-	// GetFileState returns the current state of a file
+	// GetFileState returns the current state of a file without its content hash
 	GetFileState(path string) (FileState, error)
 	// ResolvePath converts a path to its canonical form
 	ResolvePath(path string) (string, error)
+	// Hash returns the hex-encoded SHA-256 hash of the content of a file
+	Hash(path string) (string, error)
+}
+
+// Options configures Watchers for local files
+type Options struct {
+	// CompareContent reports a file as changed only when its content changes,
+	// so that touching a file is ignored. A file is hashed again when its modification time or size changes;
+	// event-driven watchers also hash it after every write, which notices rewrites within the mtime resolution
+	CompareContent bool
 }
 
 // Watcher defines the interface for file monitoring
@@ -41,15 +124,23 @@ type Watcher interface {
 	// The files parameter is a slice of file paths to watch
 	// The events channel receives events when files change
 	Watch(ctx context.Context, files []string, events chan<- Event) error
+	// Add starts watching another file while watching is in progress.
+	// Adding a file that is already watched has no effect.
+	Add(path string) error
+	// Remove stops watching a file while watching is in progress.
+	// Removing a file that is not watched has no effect.
+	Remove(path string) error
 	// Close stops watching and cleans up resources
 	Close() error
 }
 
+// ErrNotWatching is returned by Add and Remove when Watch has not been called
+var ErrNotWatching = errors.New("watcher is not watching")
+
 // FileChange represents a detected change in a file
 type FileChange struct {
-	Path    string
-	IsNew   bool
-	IsError bool
+	Path string
+	Op   Op
 }
 
 // CheckFiles compares current and previous file states to detect changes
@@ -57,38 +148,49 @@ type FileChange struct {
 func CheckFiles(current, previous map[string]FileState) []FileChange {
 	changes := make([]FileChange, 0)
 
-	// Check for modified or new files
+	// Check for modified, created or removed files
 	for path, currentState := range current {
 		if prevState, exists := previous[path]; exists {
 			if !currentState.Equal(prevState) {
-				changes = append(changes, FileChange{Path: path, IsNew: false})
+				changes = append(changes, FileChange{Path: path, Op: changeOp(prevState, currentState)})
 			}
-		} else {
-			changes = append(changes, FileChange{Path: path, IsNew: true})
+		} else if currentState.Exists {
+			changes = append(changes, FileChange{Path: path, Op: Create})
 		}
 	}
 
-	// Check for error states (files that no longer exist)
+	// Check for error states (files whose state is no longer available)
 	for path := range previous {
 		if _, exists := current[path]; !exists {
-			changes = append(changes, FileChange{Path: path, IsError: true})
+			changes = append(changes, FileChange{Path: path, Op: Error})
 		}
 	}
 
 	return changes
 }
 
+// changeOp returns the kind of change between two different states of a file
+func changeOp(previous, current FileState) Op {
+	switch {
+	case !previous.Exists && current.Exists:
+		return Create
+	case previous.Exists && !current.Exists:
+		return Remove
+	default:
+		return Write
+	}
+}
+
 // CreateEvents converts file changes to events
 // This is a pure function that can be easily tested
 func CreateEvents(changes []FileChange, isRemote bool) []Event {
 	events := make([]Event, 0, len(changes))
 	for _, change := range changes {
-		if !change.IsError { // Only create events for valid changes
-			events = append(events, Event{
-				FilePath: change.Path,
-				IsRemote: isRemote,
-			})
-		}
+		events = append(events, Event{
+			FilePath: change.Path,
+			IsRemote: isRemote,
+			Op:       change.Op,
+		})
 	}
 	return events
 }
@@ -114,3 +216,47 @@ func GetFileStates(fs FileSystem, paths []string) (map[string]FileState, error)
 
 	return states, nil
 }
+
+// readStates retrieves the current states of files, with content hashes when hash is true.
+// Hashes of previous states are reused for files that were not modified.
+func readStates(fs FileSystem, paths []string, previous map[string]FileState, hash bool) (map[string]FileState, error) {
+	states, err := GetFileStates(fs, paths)
+	if err != nil {
+		return nil, err
+	}
+	if hash {
+		if err := HashStates(fs, states, previous); err != nil {
+			return nil, err
+		}
+	}
+	return states, nil
+}
+
+// HashStates computes the content hashes of the existing files in current.
+// The hash of the previous state is reused while the modification time and size are unchanged,
+// so that a file is read only after it was modified. Directories are never hashed.
+func HashStates(fs FileSystem, current, previous map[string]FileState) error {
+	for path, state := range current {
+		if !state.Exists || state.IsDir {
+			continue
+		}
+		if prev, ok := previous[path]; ok && prev.Hash != "" && prev.Size == state.Size && prev.ModTime.Equal(state.ModTime) {
+			state.Hash = prev.Hash
+			current[path] = state
+			continue
+		}
+
+		hash, err := fs.Hash(path)
+		if errors.Is(err, iofs.ErrNotExist) {
+			// The file was removed after its state was read
+			current[path] = FileState{Path: path, Exists: false}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("hashing %s: %w", path, err)
+		}
+		state.Hash = hash
+		current[path] = state
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ func TestFeatures(t *testing.T) {
 			Paths: []string{
 				"../../features/local_file_monitoring.feature",
 				"../../features/config_file_handling.feature",
+				"../../features/standard_input_handling.feature",
 			},
 			TestingT: t,
 			// タグ指定を削除して全てのシナリオが実行されるようにする
@@ -37,6 +37,9 @@ type testContext struct {
 	stdoutWriter  *os.File      // 標準出力のライター
 	stderrReader  *os.File      // 標準エラー出力のリーダー
 	stderrWriter  *os.File      // 標準エラー出力のライター
+	stdin         *string       // 標準入力に渡す内容（nilなら標準入力を差し替えない）
+	origStdin     *os.File      // 元の標準入力
+	origDir       string        // 元の作業ディレクトリ
 }
 
 func newTestContext() *testContext {
@@ -44,7 +47,7 @@ func newTestContext() *testContext {
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create test directory: %v", err))
 	}
-	w, err := watcher.NewLocalWatcher()
+	w, err := watcher.NewLocalWatcher(watcher.Options{})
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create watcher: %v", err))
 	}
@@ -76,6 +79,12 @@ func (tc *testContext) cleanup() {
 	tc.wg.Wait() // goroutineの終了を待つ
 	// 標準出力と標準エラー出力を元に戻す
 	tc.restoreStdoutAndStderr()
+	tc.restoreStdin()
+	// 作業ディレクトリを元に戻す
+	if tc.origDir != "" {
+		os.Chdir(tc.origDir)
+		tc.origDir = ""
+	}
 	if tc.watcher != nil {
 		tc.watcher.Close()
 	}
@@ -129,6 +138,41 @@ func (tc *testContext) restoreStdoutAndStderr() {
 	}
 }
 
+// 標準入力を指定された内容を読み出すパイプに差し替える
+func (tc *testContext) redirectStdin(content string) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe for stdin: %v", err)
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		return fmt.Errorf("failed to write stdin content: %v", err)
+	}
+	w.Close()
+	tc.origStdin = os.Stdin
+	os.Stdin = r
+	return nil
+}
+
+// 標準入力を元に戻す
+func (tc *testContext) restoreStdin() {
+	if tc.origStdin != nil {
+		os.Stdin.Close()
+		os.Stdin = tc.origStdin
+		tc.origStdin = nil
+	}
+}
+
+// 標準入力に渡す内容を設定
+func (tc *testContext) stdinHasContent(content string) error {
+	tc.stdin = &content
+	return nil
+}
+
+// 標準入力に何も渡さない
+func (tc *testContext) stdinIsEmpty() error {
+	return tc.stdinHasContent("")
+}
+
 func (tc *testContext) thereIsFileWithContent(filename, content string) error {
 	path := filepath.Join(tc.dir, filename)
 	return os.WriteFile(path, []byte(content), 0644)
@@ -210,6 +254,23 @@ func (tc *testContext) executeWampaCommand(command string) error {
 	fmt.Printf("処理後の引数: %v\n", cmdArgs)
 	fmt.Printf("出力ファイル: %s\n", tc.outputPath)
 
+	// 利用者と同じくテストディレクトリでwampaを実行し、セクションのパスをそこからの相対パスにする
+	origDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tc.dir); err != nil {
+		return fmt.Errorf("failed to change directory: %v", err)
+	}
+	tc.origDir = origDir
+
+	// 標準入力に渡す内容があればパイプに差し替える
+	if tc.stdin != nil {
+		if err := tc.redirectStdin(*tc.stdin); err != nil {
+			return err
+		}
+	}
+
 	// 別goroutineでRun関数を実行
 	tc.wg.Add(1)
 	go func() {
@@ -248,6 +309,16 @@ func (tc *testContext) outputFileContains(content string) error {
 	return nil
 }
 
+// コマンドの終了を待ってファイルの内容を確認
+func (tc *testContext) fileHasContent(filename, content string) error {
+	select {
+	case <-tc.cmdDone:
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("timeout waiting for wampa to exit")
+	}
+	return tc.checkFileContent(filepath.Join(tc.dir, filename), content)
+}
+
 func (tc *testContext) outputFileUpdatedWithin5Seconds(content string) error {
 	return tc.waitForContent(content, 5*time.Second)
 }
@@ -321,6 +392,8 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 		return ctx, nil
 	})
 	ctx.Step(`^以下の内容の([^"]*)が存在する:$`, testCtx.thereIsFileWithContent)
+	ctx.Step(`^標準入力に以下の内容を渡す:$`, testCtx.stdinHasContent)
+	ctx.Step(`^標準入力に何も渡さない$`, testCtx.stdinIsEmpty)
 	ctx.Step(`^wampaを以下のコマンドで実行:$`, testCtx.executeWampaCommand)
 	ctx.Step(`^wampaをパラメータなしで実行:$`, testCtx.executeWampaWithNoParams)
 	ctx.Step(`^カレントディレクトリにwampa\.jsonが存在しない状態でwampaをパラメータなしで実行:$`, testCtx.executeWampaCommandWithoutConfig)
@@ -328,6 +401,7 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 	ctx.Step(`^output\.mdは以下の内容を含む:$`, testCtx.outputFileContains)
 	ctx.Step(`^([^"]*)は以下の内容を含む:$`, testCtx.outputFileContains)
 	ctx.Step(`^([^"]*)は作成されない$`, testCtx.outputFileDoesNotExist)
+	ctx.Step(`^([^"]*)の内容は以下の通り:$`, testCtx.fileHasContent)
 	ctx.Step(`^([^"]*)を以下の内容に変更:$`, testCtx.thereIsFileWithContent)
 	ctx.Step(`^5秒以内にoutput\.mdは以下の内容に更新される:$`, testCtx.outputFileUpdatedWithin5Seconds)
 	ctx.Step(`^以下のヘルプメッセージが表示される:$`, testCtx.helpMessageIsDisplayed)